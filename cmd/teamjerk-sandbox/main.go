@@ -0,0 +1,38 @@
+// teamjerk-sandbox runs the fake Teamwork server,
+// so teamjerk can be tried without touching the real data
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+
+	"github.com/harnyk/teamjerk/internal/twfake"
+)
+
+func main() {
+	addr := flag.String("addr", "127.0.0.1:8080", "Address to listen on")
+	otp := flag.String("otp", "", "Require this two-factor authentication code to log in")
+	flag.Parse()
+
+	listener, err := net.Listen("tcp", *addr)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fake := twfake.NewServer()
+	fake.OTP = *otp
+
+	url := "http://" + listener.Addr().String()
+
+	fmt.Printf("Fake Teamwork is running at %s, the data is kept in memory only.\n\n", url)
+	fmt.Printf("Use a separate profile, so your real credentials are not replaced:\n\n")
+	fmt.Printf("    export TEAMJERK_PROFILE=sandbox TEAMJERK_LAUNCHPAD_URL=%s\n", url)
+	fmt.Printf("    teamjerk login    # email: %s, password: %s\n", twfake.Email, twfake.Password)
+	fmt.Printf("    teamjerk tasks\n\n")
+	fmt.Printf("API key for 'teamjerk login --api-key --url %s': %s\n", url, twfake.APIKey)
+
+	log.Fatal(http.Serve(listener, fake))
+}
@@ -1,39 +1,506 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"log"
+	"net/url"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"strings"
+	"syscall"
 	"time"
 
 	"github.com/harnyk/teamjerk/internal/app"
 	"github.com/harnyk/teamjerk/internal/authstore"
+	"github.com/harnyk/teamjerk/internal/cache"
+	"github.com/harnyk/teamjerk/internal/config"
+	"github.com/harnyk/teamjerk/internal/journal"
 	"github.com/harnyk/teamjerk/internal/twapi"
+	"github.com/howeyc/gopass"
 	"github.com/spf13/cobra"
 )
 
 //this will be replaced in the goreleaser build
 var version = "development"
 
-func getAuthFilePath() (string, error) {
+// Exit codes, documented in the Readme
+const (
+	exitCodeError = 1
+	// the command line is invalid (unknown command or flag, wrong arguments)
+	exitCodeUsage = 2
+	// not logged in, or the stored credentials have been rejected
+	exitCodeAuth = 3
+	// the user has no permission for the operation
+	exitCodeForbidden = 4
+	// the project, task or time entry does not exist
+	exitCodeNotFound = 5
+	// Teamwork has rejected the request as invalid
+	exitCodeValidation = 6
+	// Teamwork is unreachable or failing, or the --timeout has expired
+	exitCodeNetwork = 7
+	// the command has been cancelled with Ctrl-C, as the shells report SIGINT
+	exitCodeInterrupted = 130
+)
+
+// getExitCode maps the error to the exit code of its class
+func getExitCode(err error) int {
+	// the failed connections are reported by the http client as *url.Error
+	var urlErr *url.Error
+
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return exitCodeNetwork
+	case errors.Is(err, app.ErrSessionExpired),
+		errors.Is(err, app.ErrNotLoggedIn),
+		errors.Is(err, twapi.ErrUnauthorized):
+		return exitCodeAuth
+	case errors.Is(err, twapi.ErrForbidden):
+		return exitCodeForbidden
+	case errors.Is(err, twapi.ErrNotFound):
+		return exitCodeNotFound
+	case errors.Is(err, twapi.ErrValidation):
+		return exitCodeValidation
+	case errors.Is(err, twapi.ErrServer),
+		errors.As(err, &urlErr):
+		return exitCodeNetwork
+	}
+
+	return exitCodeError
+}
+
+// getSetting returns the value of the flag,
+// falling back to the environment variable and then to the config file
+func getSetting(cmd *cobra.Command, flag, env, configValue string) (string, error) {
+	value, err := cmd.Flags().GetString(flag)
+	if err != nil {
+		return "", err
+	}
+	if value == "" {
+		value = os.Getenv(env)
+	}
+	if value == "" {
+		value = configValue
+	}
+
+	return value, nil
+}
+
+// getClientOptions returns the options of the Teamwork client
+// set with the flags, the environment variables or the config file
+func getClientOptions(cmd *cobra.Command, cfg *config.Config) ([]twapi.Option, error) {
+	var options []twapi.Option
+
+	launchpadURL, err := getSetting(cmd, "launchpad-url", "TEAMJERK_LAUNCHPAD_URL", cfg.LaunchpadURL)
+	if err != nil {
+		return nil, err
+	}
+	if launchpadURL != "" {
+		options = append(options, twapi.WithLaunchpadURL(launchpadURL))
+	}
+
+	apiEndPoint, err := getSetting(cmd, "api-endpoint", "TEAMJERK_API_ENDPOINT", cfg.APIEndPoint)
+	if err != nil {
+		return nil, err
+	}
+	if apiEndPoint != "" {
+		options = append(options, twapi.WithAPIEndPoint(apiEndPoint))
+	}
+
+	proxy, err := getSetting(cmd, "proxy", "TEAMJERK_PROXY", cfg.Proxy)
+	if err != nil {
+		return nil, err
+	}
+	if proxy != "" {
+		proxyURL, err := url.Parse(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy URL: %w", err)
+		}
+		options = append(options, twapi.WithProxy(proxyURL))
+	}
+
+	caBundle, err := getSetting(cmd, "ca-bundle", "TEAMJERK_CA_BUNDLE", cfg.CABundle)
+	if err != nil {
+		return nil, err
+	}
+	if caBundle != "" {
+		pool, err := twapi.LoadCABundle(caBundle)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load the CA bundle: %w", err)
+		}
+		options = append(options, twapi.WithRootCAs(pool))
+	}
+
+	record := os.Getenv("TEAMJERK_RECORD")
+	replay := os.Getenv("TEAMJERK_REPLAY")
+	switch {
+	case record != "" && replay != "":
+		return nil, errors.New("TEAMJERK_RECORD and TEAMJERK_REPLAY can't be used together")
+	case record != "":
+		options = append(options, twapi.WithRecorder(record))
+	case replay != "":
+		options = append(options, twapi.WithReplay(replay))
+	}
+
+	return options, nil
+}
+
+// getCacheOptions returns the cache settings of the profile.
+// The TTL is taken from TEAMJERK_CACHE_TTL or the config file.
+func getCacheOptions(cmd *cobra.Command, cfg *config.Config, dir string) (app.CacheOptions, error) {
+	refresh, err := cmd.Flags().GetBool("refresh")
+	if err != nil {
+		return app.CacheOptions{}, err
+	}
+
+	ttl := defaultCacheTTL
+	ttlS := os.Getenv("TEAMJERK_CACHE_TTL")
+	if ttlS == "" {
+		ttlS = cfg.CacheTTL
+	}
+	if ttlS != "" {
+		ttl, err = time.ParseDuration(ttlS)
+		if err != nil {
+			return app.CacheOptions{}, fmt.Errorf("invalid cache TTL: %w", err)
+		}
+	}
+
+	return app.CacheOptions{Dir: dir, TTL: ttl, Refresh: refresh}, nil
+}
+
+// getTraceWriter returns where the HTTP requests are traced to, or nil if tracing is off.
+// The --debug flag takes precedence over TEAMJERK_DEBUG,
+// both accept "stderr" (also "1" or "true" for the env) or a file name.
+func getTraceWriter(cmd *cobra.Command) (io.Writer, func() error, error) {
+	target, err := cmd.Flags().GetString("debug")
+	if err != nil {
+		return nil, nil, err
+	}
+	if !cmd.Flags().Changed("debug") {
+		target = os.Getenv("TEAMJERK_DEBUG")
+	}
+
+	switch strings.ToLower(target) {
+	case "", "0", "false":
+		return nil, nil, nil
+	case "stderr", "1", "true":
+		return os.Stderr, func() error { return nil }, nil
+	}
+
+	// the trace is redacted, but still contains the personal data
+	file, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return file, file.Close, nil
+}
+
+// getLogRange returns the range of dates to log set by --from and --to, --week or --month,
+// or zero dates if the time is logged on a single date
+func getLogRange(cmd *cobra.Command, date time.Time) (time.Time, time.Time, error) {
+	flags := cmd.Flags()
+
+	ranged := flags.Changed("from") || flags.Changed("to")
+	week, err := flags.GetBool("week")
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	month, err := flags.GetBool("month")
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+
+	switch {
+	case ranged && (week || month), week && month:
+		return time.Time{}, time.Time{}, errors.New("only one of --from/--to, --week and --month can be given")
+	case ranged && !date.IsZero():
+		return time.Time{}, time.Time{}, errors.New("--date can't be given with --from/--to")
+	case ranged && !(flags.Changed("from") && flags.Changed("to")):
+		return time.Time{}, time.Time{}, errors.New("both --from and --to are required")
+	}
+
+	if ranged {
+		from, err := getDateFlag(cmd, "from", time.Time{})
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+		to, err := getDateFlag(cmd, "to", time.Time{})
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+		if to.Before(from) {
+			return time.Time{}, time.Time{}, errors.New("--to must not be before --from")
+		}
+
+		return from, to, nil
+	}
+
+	// the week or the month of the date, or of today
+	anchor := date
+	if anchor.IsZero() {
+		now := time.Now()
+		anchor = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	}
+
+	switch {
+	case week:
+		// the weeks start on Monday
+		monday := anchor.AddDate(0, 0, -(int(anchor.Weekday())+6)%7)
+		return monday, monday.AddDate(0, 0, 6), nil
+	case month:
+		first := time.Date(anchor.Year(), anchor.Month(), 1, 0, 0, 0, 0, time.UTC)
+		return first, first.AddDate(0, 1, -1), nil
+	}
+
+	return time.Time{}, time.Time{}, nil
+}
+
+// getHolidays returns the holidays of the config file
+func getHolidays(cfg *config.Config) ([]time.Time, error) {
+	holidays := []time.Time{}
+
+	for _, holiday := range cfg.Holidays {
+		date, err := time.Parse("2006-01-02", holiday)
+		if err != nil {
+			return nil, fmt.Errorf("invalid holiday in the config file: %w", err)
+		}
+		holidays = append(holidays, date)
+	}
+
+	return holidays, nil
+}
+
+func addEntriesFilterFlags(cmd *cobra.Command) {
+	cmd.Flags().String("from", "", "First date (e.g. 2020-01-01), the beginning of the current month by default")
+	cmd.Flags().String("to", "", "Last date (e.g. 2020-01-31), the end of the current month by default")
+	cmd.Flags().Uint64P("project-id", "p", 0, "Project ID")
+	cmd.Flags().Uint64P("task-id", "t", 0, "Task ID")
+	cmd.Flags().Uint64("user-id", 0, "User ID, the current user by default")
+}
+
+// getEntriesFilter returns the filter set by the flags added with addEntriesFilterFlags
+func getEntriesFilter(cmd *cobra.Command) (app.EntriesFilter, error) {
+	now := time.Now()
+	beginningOfMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	from, err := getDateFlag(cmd, "from", beginningOfMonth)
+	if err != nil {
+		return app.EntriesFilter{}, err
+	}
+
+	to, err := getDateFlag(cmd, "to", beginningOfMonth.AddDate(0, 1, -1))
+	if err != nil {
+		return app.EntriesFilter{}, err
+	}
+
+	if to.Before(from) {
+		return app.EntriesFilter{}, fmt.Errorf("--to must not be before --from")
+	}
+
+	filter := app.EntriesFilter{From: from, To: to}
+
+	for flag, id := range map[string]*twapi.ID{
+		"project-id": &filter.ProjectID,
+		"task-id":    &filter.TaskID,
+		"user-id":    &filter.UserID,
+	} {
+		value, err := cmd.Flags().GetUint64(flag)
+		if err != nil {
+			return app.EntriesFilter{}, err
+		}
+		*id = twapi.ID(value)
+	}
+
+	return filter, nil
+}
+
+// getDateFlag returns the date in the flag, or the default if the flag is empty
+func getDateFlag(cmd *cobra.Command, name string, defaultDate time.Time) (time.Time, error) {
+	dateS, err := cmd.Flags().GetString(name)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if dateS == "" {
+		return defaultDate, nil
+	}
+
+	date, err := time.Parse("2006-01-02", dateS)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid --%s date: %w", name, err)
+	}
+
+	return date, nil
+}
+
+func getStateDir() (string, error) {
 	home, err := os.UserHomeDir()
 	if err != nil {
 		return "", err
 	}
 
-	return filepath.Join(home, ".teamjerk", "auth.json"), nil
+	return filepath.Join(home, ".teamjerk"), nil
+}
+
+// getAuthStoreKind returns the configured credential store backend.
+// TEAMJERK_AUTH_STORE takes precedence over the config file.
+func getAuthStoreKind(cfg *config.Config) (string, error) {
+	kind := os.Getenv("TEAMJERK_AUTH_STORE")
+	if kind == "" {
+		kind = cfg.AuthStore
+	}
+	if kind == "" {
+		kind = config.AuthStorePlain
+	}
+
+	if kind != config.AuthStorePlain && kind != config.AuthStoreEncrypted {
+		return "", fmt.Errorf("invalid auth store: %q", kind)
+	}
+
+	return kind, nil
+}
+
+const defaultProfile = "default"
+
+const defaultCacheTTL = time.Hour
+
+// newProfiles returns the registry of profiles kept in the given store backend.
+// Every profile is a separate credential store in ~/.teamjerk/profiles.
+func newProfiles(stateDir, kind string) authstore.Registry[twapi.AuthData] {
+	profilesDir := filepath.Join(stateDir, "profiles")
+
+	if kind == config.AuthStoreEncrypted {
+		return authstore.NewRegistry(profilesDir, ".enc.json",
+			func(file string) authstore.AuthStore[twapi.AuthData] {
+				return authstore.NewEncryptedAuthStore[twapi.AuthData](file, getPassphrase)
+			},
+		)
+	}
+
+	return authstore.NewRegistry(profilesDir, ".json",
+		func(file string) authstore.AuthStore[twapi.AuthData] {
+			return authstore.NewAuthStore[twapi.AuthData](file)
+		},
+	)
+}
+
+// migrateLegacyAuthFiles moves the single-account credential files
+// used before profiles were introduced to the default profile
+func migrateLegacyAuthFiles(stateDir string) error {
+	legacyFiles := map[string]string{
+		"auth.json":     ".json",
+		"auth.enc.json": ".enc.json",
+	}
+
+	for legacyFile, ext := range legacyFiles {
+		err := authstore.MoveIntoRegistry(filepath.Join(stateDir, legacyFile), filepath.Join(stateDir, "profiles"), defaultProfile, ext)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// getProfileName returns the name of the profile to use.
+// The --profile flag takes precedence over TEAMJERK_PROFILE,
+// which takes precedence over the config file.
+func getProfileName(cmd *cobra.Command, cfg *config.Config) (string, error) {
+	profile, err := cmd.Flags().GetString("profile")
+	if err != nil {
+		return "", err
+	}
+	if profile == "" {
+		profile = os.Getenv("TEAMJERK_PROFILE")
+	}
+	if profile == "" {
+		profile = cfg.Profile
+	}
+	if profile == "" {
+		profile = defaultProfile
+	}
+
+	return profile, authstore.ValidateName(profile)
+}
+
+// enteredPassphrase keeps the interactively entered passphrase,
+// so it is asked only once even if several profiles are accessed
+var enteredPassphrase string
+
+// getPassphrase returns the passphrase for the encrypted credential store
+// from TEAMJERK_PASSPHRASE, or asks for it interactively
+func getPassphrase(confirm bool) (string, error) {
+	if passphrase := os.Getenv("TEAMJERK_PASSPHRASE"); passphrase != "" {
+		return passphrase, nil
+	}
+	if enteredPassphrase != "" {
+		return enteredPassphrase, nil
+	}
+
+	passphrase, err := gopass.GetPasswdPrompt("Passphrase: ", false, os.Stdin, os.Stderr)
+	if err != nil {
+		return "", err
+	}
+
+	if confirm {
+		confirmation, err := gopass.GetPasswdPrompt("Repeat passphrase: ", false, os.Stdin, os.Stderr)
+		if err != nil {
+			return "", err
+		}
+		if string(confirmation) != string(passphrase) {
+			return "", fmt.Errorf("passphrases do not match")
+		}
+	}
+
+	if len(passphrase) == 0 {
+		return "", fmt.Errorf("passphrase must not be empty")
+	}
+
+	enteredPassphrase = string(passphrase)
+
+	return enteredPassphrase, nil
 }
 
 func main() {
-	authFilePath, err := getAuthFilePath()
+	stateDir, err := getStateDir()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	configFilePath := filepath.Join(stateDir, "config.json")
+	cfg, err := config.Load(configFilePath)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	tw := twapi.NewClient()
-	store := authstore.NewAuthStore[twapi.AuthData](authFilePath)
-	a := app.NewApp(tw, store)
+	authStoreKind, err := getAuthStoreKind(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	err = migrateLegacyAuthFiles(stateDir)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	profiles := newProfiles(stateDir, authStoreKind)
+	cacheDir := filepath.Join(stateDir, "cache")
+	journalFile := filepath.Join(stateDir, "journal.json")
+
+	// the app is created once the flags are parsed and the profile is known
+	var a app.App
+
+	// closeTrace closes the file of the --debug flag
+	closeTrace := func() error { return nil }
+
+	// commandLineValid tells the usage errors from the errors of the command itself
+	commandLineValid := false
+
+	// cancelTimeout releases the timer of the --timeout flag
+	cancelTimeout := context.CancelFunc(func() {})
 
 	rootCmd := &cobra.Command{
 		Use:   "teamjerk",
@@ -42,23 +509,160 @@ func main() {
 		Run: func(cmd *cobra.Command, args []string) {
 			cmd.Help()
 		},
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			// the command line is valid at this point,
+			// so the usage is not printed for the errors that follow
+			cmd.SilenceUsage = true
+			commandLineValid = true
+
+			timeout, err := cmd.Flags().GetDuration("timeout")
+			if err != nil {
+				return err
+			}
+			if timeout > 0 {
+				var ctx context.Context
+				ctx, cancelTimeout = context.WithTimeout(cmd.Context(), timeout)
+				cmd.SetContext(ctx)
+			}
+
+			profile, err := getProfileName(cmd, cfg)
+			if err != nil {
+				return err
+			}
+
+			store, err := profiles.Get(profile)
+			if err != nil {
+				return err
+			}
+
+			options, err := getClientOptions(cmd, cfg)
+			if err != nil {
+				return err
+			}
+
+			trace, closeTraceFile, err := getTraceWriter(cmd)
+			if err != nil {
+				return err
+			}
+			if trace != nil {
+				options = append(options, twapi.WithTrace(trace))
+				closeTrace = closeTraceFile
+			}
+
+			cacheOptions, err := getCacheOptions(cmd, cfg, filepath.Join(cacheDir, profile))
+			if err != nil {
+				return err
+			}
+
+			a = app.NewApp(twapi.NewClient(options...), store, profile, cacheOptions, journal.New(journalFile))
+
+			return nil
+		},
 	}
+	rootCmd.PersistentFlags().String("profile", "", "Profile to use (env: TEAMJERK_PROFILE)")
+	rootCmd.PersistentFlags().Bool("refresh", false, "Fetch the profile, projects and tasks again instead of using the cached ones")
+	rootCmd.PersistentFlags().String("launchpad-url", "", "Launchpad URL used to find the accounts on login (env: TEAMJERK_LAUNCHPAD_URL)")
+	rootCmd.PersistentFlags().String("api-endpoint", "", "Send the requests to this URL instead of the API endpoint of the installation (env: TEAMJERK_API_ENDPOINT)")
+	rootCmd.PersistentFlags().String("proxy", "", "HTTP proxy URL (env: TEAMJERK_PROXY, HTTPS_PROXY)")
+	rootCmd.PersistentFlags().String("ca-bundle", "", "PEM file with additional trusted certificate authorities (env: TEAMJERK_CA_BUNDLE)")
+	rootCmd.PersistentFlags().String("debug", "", "Trace the HTTP requests to stderr, or to the file if specified: --debug=trace.log (env: TEAMJERK_DEBUG)")
+	rootCmd.PersistentFlags().Lookup("debug").NoOptDefVal = "stderr"
+	rootCmd.PersistentFlags().Duration("timeout", 0, "Abort the command if it takes longer, e.g. 30s or 2m (0 means no limit)")
 
 	loginCmd := &cobra.Command{
 		Use:   "login",
 		Short: "Login to Teamwork.com",
-		Long:  `Login to Teamwork.com`,
+		Long: `Login to Teamwork.com.
+
+By default, you are asked for your email and password, and for
+the two-factor authentication code if your account requires it.
+
+For non-interactive use, the email is taken from --email or TEAMJERK_EMAIL.
+The password is read from the standard input with --password-stdin,
+or taken from TEAMJERK_PASSWORD, or printed by the "password_command"
+from the config file (e.g. "pass show teamwork").
+If you have several accounts, choose one with --account.
+
+With --api-key, a personal API key is used instead. It is taken
+from TEAMJERK_API_KEY or asked interactively.
+
+If your company enforces SSO, log in with the browser and import the session:
+with --cookie, paste the value of the 'tw-auth' cookie (or set TEAMJERK_COOKIE);
+with --cookie-db, it is read from the browser cookie database, e.g.
+~/.mozilla/firefox/<profile>/cookies.sqlite or ~/.config/chromium/Default/Cookies
+(requires the sqlite3 tool).`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return a.LogIn()
+			apiKey, err := cmd.Flags().GetBool("api-key")
+			if err != nil {
+				return err
+			}
+
+			installationURL, err := cmd.Flags().GetString("url")
+			if err != nil {
+				return err
+			}
+
+			otp, err := cmd.Flags().GetString("otp")
+			if err != nil {
+				return err
+			}
+
+			email, err := cmd.Flags().GetString("email")
+			if err != nil {
+				return err
+			}
+
+			passwordStdin, err := cmd.Flags().GetBool("password-stdin")
+			if err != nil {
+				return err
+			}
+
+			account, err := cmd.Flags().GetString("account")
+			if err != nil {
+				return err
+			}
+
+			cookie, err := cmd.Flags().GetBool("cookie")
+			if err != nil {
+				return err
+			}
+
+			cookieDB, err := cmd.Flags().GetString("cookie-db")
+			if err != nil {
+				return err
+			}
+
+			loginOptions := app.LoginOptions{
+				Email:           email,
+				PasswordStdin:   passwordStdin,
+				PasswordCommand: cfg.PasswordCommand,
+				Account:         account,
+				APIKey:          apiKey,
+				Cookie:          cookie,
+				CookieDB:        cookieDB,
+				URL:             installationURL,
+				OTP:             otp,
+			}
+
+			return a.LogIn(cmd.Context(), loginOptions)
 		},
 	}
+	loginCmd.Flags().String("email", "", "Email (env: TEAMJERK_EMAIL)")
+	loginCmd.Flags().Bool("password-stdin", false, "Read the password from the standard input")
+	loginCmd.Flags().String("account", "", "Installation ID or name to log in to, if there are several")
+	loginCmd.Flags().Bool("api-key", false, "Log in with a personal API key")
+	loginCmd.Flags().Bool("cookie", false, "Log in with the 'tw-auth' cookie copied from the browser")
+	loginCmd.Flags().String("cookie-db", "", "Read the 'tw-auth' cookie from a Firefox or Chromium cookie database file")
+	loginCmd.MarkFlagsMutuallyExclusive("api-key", "cookie", "cookie-db")
+	loginCmd.Flags().String("url", "", "Installation URL (e.g. https://example.teamwork.com)")
+	loginCmd.Flags().String("otp", "", "Two-factor authentication code")
 
 	logoutCmd := &cobra.Command{
 		Use:   "logout",
 		Short: "Logout from Teamwork.com",
 		Long:  `Logout from Teamwork.com`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return a.LogOut()
+			return a.LogOut(cmd.Context())
 		},
 	}
 
@@ -67,7 +671,7 @@ func main() {
 		Short: "Show the currently logged in user",
 		Long:  `Show the currently logged in user`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return a.WhoAmI()
+			return a.WhoAmI(cmd.Context())
 		},
 	}
 
@@ -76,7 +680,7 @@ func main() {
 		Short: "List all projects",
 		Long:  `List all projects`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return a.Projects()
+			return a.Projects(cmd.Context())
 		},
 	}
 
@@ -85,7 +689,7 @@ func main() {
 		Short: "List all tasks",
 		Long:  `List all tasks`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return a.Tasks()
+			return a.Tasks(cmd.Context())
 		},
 	}
 
@@ -171,18 +775,33 @@ func main() {
 
 			//------------------------------------------------------------------
 
+			from, to, err := getLogRange(cmd, date)
+			if err != nil {
+				return err
+			}
+
+			holidays, err := getHolidays(cfg)
+			if err != nil {
+				return err
+			}
+
+			//------------------------------------------------------------------
+
 			logOptions := app.LogOptions{
 				DryRun:      dryRun,
 				NonBillable: nonBillable,
-				ProjectID:   projectID,
-				TaskID:      taskID,
+				ProjectID:   twapi.ID(projectID),
+				TaskID:      twapi.ID(taskID),
 				Date:        date,
 				StartTime:   startTime,
 				Description: description,
 				Duration:    duration,
+				From:        from,
+				To:          to,
+				Holidays:    holidays,
 			}
 
-			return a.Log(logOptions)
+			return a.Log(cmd.Context(), logOptions)
 		},
 	}
 	logCmd.Flags().BoolP("dry-run", "n", false, "Don't actually log time")
@@ -193,6 +812,10 @@ func main() {
 	logCmd.Flags().StringP("time", "s", "", "Start time (e.g. 09:00)")
 	logCmd.Flags().Float64P("duration", "u", 0, "Number of logged hours (e.g. 8.5)")
 	logCmd.Flags().StringP("description", "D", "", "Description")
+	logCmd.Flags().String("from", "", "Log every working day from this date (e.g. 2020-01-01), requires --to")
+	logCmd.Flags().String("to", "", "Log every working day up to this date (e.g. 2020-01-31), requires --from")
+	logCmd.Flags().Bool("week", false, "Log every working day of the week of --date or of the current week")
+	logCmd.Flags().Bool("month", false, "Log every working day of the month of --date or of the current month")
 
 	reportCmd := &cobra.Command{
 		Use:   "report",
@@ -232,13 +855,497 @@ func main() {
 				return err
 			}
 
-			return a.Report(beginningOfMonth, outputFileName)
+			return a.Report(cmd.Context(), beginningOfMonth, outputFileName)
 		},
 	}
 	reportCmd.Flags().IntP("year", "y", time.Now().Year(), "Year to report")
 	reportCmd.Flags().IntP("month", "m", int(time.Now().Month()), "Month to report")
 	reportCmd.Flags().StringP("output", "o", "", "Output JSON file")
 
+	historyCmd := &cobra.Command{
+		Use:   "history",
+		Short: "Show the time entries logged with teamjerk",
+		Long: `Show the time entries logged with teamjerk in the current profile,
+the most recent last. They are recorded in ~/.teamjerk/journal.json.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			limit, err := cmd.Flags().GetInt("limit")
+			if err != nil {
+				return err
+			}
+
+			asJSON, err := cmd.Flags().GetBool("json")
+			if err != nil {
+				return err
+			}
+
+			return a.History(app.HistoryOptions{Limit: limit, JSON: asJSON})
+		},
+	}
+	historyCmd.Flags().IntP("limit", "l", 20, "Number of the most recent entries to show, 0 for all")
+	historyCmd.Flags().Bool("json", false, "Output as JSON")
+
+	undoCmd := &cobra.Command{
+		Use:   "undo",
+		Short: "Delete the time entry logged last with teamjerk",
+		Long: `Delete the time entry logged last with teamjerk in the current profile.
+Running it again deletes the one logged before, and so on.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			yes, err := cmd.Flags().GetBool("yes")
+			if err != nil {
+				return err
+			}
+
+			return a.Undo(cmd.Context(), yes)
+		},
+	}
+	undoCmd.Flags().BoolP("yes", "y", false, "Don't ask for a confirmation")
+
+	entriesCmd := &cobra.Command{
+		Use:   "entries",
+		Short: "Manage logged time entries",
+		Long:  `Manage logged time entries`,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.Help()
+		},
+	}
+
+	entriesListCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the logged time entries",
+		Long: `List the logged time entries with their IDs.
+
+By default, the entries of the current user logged in the current month are listed.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			filter, err := getEntriesFilter(cmd)
+			if err != nil {
+				return err
+			}
+
+			asJSON, err := cmd.Flags().GetBool("json")
+			if err != nil {
+				return err
+			}
+
+			return a.ListEntries(cmd.Context(), app.EntriesListOptions{
+				EntriesFilter: filter,
+				JSON:          asJSON,
+			})
+		},
+	}
+	addEntriesFilterFlags(entriesListCmd)
+	entriesListCmd.Flags().Bool("json", false, "Output as JSON")
+
+	entriesEditCmd := &cobra.Command{
+		Use:   "edit <id>",
+		Short: "Edit a logged time entry",
+		Long: `Edit a logged time entry.
+
+Only the values given with the flags are changed. Without flags,
+the entry is edited interactively, starting from its current values.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			entryID, err := twapi.ParseID(args[0])
+			if err != nil {
+				return err
+			}
+
+			options := app.EntryEditOptions{}
+			flags := cmd.Flags()
+
+			//------------------------------------------------------------------
+
+			if flags.Changed("task-id") {
+				taskID, err := flags.GetUint64("task-id")
+				if err != nil {
+					return err
+				}
+				id := twapi.ID(taskID)
+				options.TaskID = &id
+			}
+
+			//------------------------------------------------------------------
+
+			if flags.Changed("date") {
+				date, err := getDateFlag(cmd, "date", time.Time{})
+				if err != nil {
+					return err
+				}
+				options.Date = &date
+			}
+
+			//------------------------------------------------------------------
+
+			if flags.Changed("time") {
+				timeS, err := flags.GetString("time")
+				if err != nil {
+					return err
+				}
+				startTime, err := time.Parse("15:04", timeS)
+				if err != nil {
+					return err
+				}
+				options.StartTime = &startTime
+			}
+
+			//------------------------------------------------------------------
+
+			if flags.Changed("duration") {
+				hours, err := flags.GetFloat64("duration")
+				if err != nil {
+					return err
+				}
+				if hours <= 0 || hours > 24 {
+					return fmt.Errorf("hours must be between 0 and 24")
+				}
+				duration := time.Duration(hours * float64(time.Hour))
+				options.Duration = &duration
+			}
+
+			//------------------------------------------------------------------
+
+			if flags.Changed("description") {
+				description, err := flags.GetString("description")
+				if err != nil {
+					return err
+				}
+				options.Description = &description
+			}
+
+			//------------------------------------------------------------------
+
+			if flags.Changed("non-billable") {
+				nonBillable, err := flags.GetBool("non-billable")
+				if err != nil {
+					return err
+				}
+				options.NonBillable = &nonBillable
+			}
+
+			//------------------------------------------------------------------
+
+			return a.EditEntry(cmd.Context(), entryID, options)
+		},
+	}
+	entriesEditCmd.Flags().BoolP("non-billable", "B", false, "Make the entry non-billable (-B=false makes it billable)")
+	entriesEditCmd.Flags().Uint64P("task-id", "t", 0, "Task ID")
+	entriesEditCmd.Flags().StringP("date", "d", "", "Date (e.g. 2020-01-31)")
+	entriesEditCmd.Flags().StringP("time", "s", "", "Start time (e.g. 09:00)")
+	entriesEditCmd.Flags().Float64P("duration", "u", 0, "Number of logged hours (e.g. 8.5)")
+	entriesEditCmd.Flags().StringP("description", "D", "", "Description")
+
+	entriesDeleteCmd := &cobra.Command{
+		Use:   "delete [id...]",
+		Short: "Delete logged time entries",
+		Long: `Delete logged time entries, either the given ones
+or the ones selected with the filter flags, e.g.
+
+    teamjerk entries delete 3074021 3074022
+    teamjerk entries delete --from 2023-03-06 --to 2023-03-10 --task-id 202
+
+The entries are shown and a confirmation is asked before deleting them.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			filtered := false
+			for _, flag := range []string{"from", "to", "project-id", "task-id", "user-id"} {
+				filtered = filtered || cmd.Flags().Changed(flag)
+			}
+
+			switch {
+			case len(args) > 0 && filtered:
+				return errors.New("either the entry IDs or the filter flags can be given, not both")
+			case len(args) == 0 && !filtered:
+				return errors.New("give the entry IDs or select the entries with the filter flags")
+			}
+
+			options := app.EntriesDeleteOptions{}
+
+			for _, arg := range args {
+				id, err := twapi.ParseID(arg)
+				if err != nil {
+					return err
+				}
+				options.IDs = append(options.IDs, id)
+			}
+
+			if filtered {
+				filter, err := getEntriesFilter(cmd)
+				if err != nil {
+					return err
+				}
+				options.EntriesFilter = filter
+			}
+
+			yes, err := cmd.Flags().GetBool("yes")
+			if err != nil {
+				return err
+			}
+			options.Yes = yes
+
+			return a.DeleteEntries(cmd.Context(), options)
+		},
+	}
+	addEntriesFilterFlags(entriesDeleteCmd)
+	entriesDeleteCmd.Flags().BoolP("yes", "y", false, "Don't ask for a confirmation")
+
+	entriesCmd.AddCommand(entriesListCmd)
+	entriesCmd.AddCommand(entriesEditCmd)
+	entriesCmd.AddCommand(entriesDeleteCmd)
+
+	authCmd := &cobra.Command{
+		Use:   "auth",
+		Short: "Manage stored credentials",
+		Long:  `Manage stored credentials`,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.Help()
+		},
+	}
+
+	authMigrateCmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "Move stored credentials to another store backend",
+		Long: `Move stored credentials to another store backend.
+
+By default, the plaintext stores of all profiles are converted
+into the encrypted ones and the configuration is switched to use them. The passphrase is taken from TEAMJERK_PASSPHRASE
+or asked interactively.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			to, err := cmd.Flags().GetString("to")
+			if err != nil {
+				return err
+			}
+
+			var from string
+			switch to {
+			case config.AuthStoreEncrypted:
+				from = config.AuthStorePlain
+			case config.AuthStorePlain:
+				from = config.AuthStoreEncrypted
+			default:
+				return fmt.Errorf("invalid auth store: %q", to)
+			}
+
+			// the environment variable takes precedence over the config file,
+			// so the migrated store wouldn't be used
+			if kind := os.Getenv("TEAMJERK_AUTH_STORE"); kind != "" && kind != to {
+				return fmt.Errorf("TEAMJERK_AUTH_STORE=%s overrides the configured store, unset it or set it to %s before migrating", kind, to)
+			}
+
+			source := newProfiles(stateDir, from)
+			target := newProfiles(stateDir, to)
+
+			names, err := source.Names()
+			if err != nil {
+				return err
+			}
+			if len(names) == 0 {
+				return fmt.Errorf("no %s credential store to migrate", from)
+			}
+
+			for _, name := range names {
+				sourceStore, err := source.Get(name)
+				if err != nil {
+					return err
+				}
+
+				targetStore, err := target.Get(name)
+				if err != nil {
+					return err
+				}
+
+				err = authstore.Migrate(sourceStore, targetStore)
+				if err != nil {
+					return err
+				}
+			}
+
+			err = config.Update(configFilePath, func(cfg *config.Config) error {
+				cfg.AuthStore = to
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("Credentials migrated to the %s store\n", to)
+
+			return nil
+		},
+	}
+	authMigrateCmd.Flags().String("to", config.AuthStoreEncrypted, "Target store backend (plain or encrypted)")
+
+	authStatusCmd := &cobra.Command{
+		Use:   "status",
+		Short: "Show the stored login and check that it is still valid",
+		Long: `Show the stored login and check that it is still valid.
+
+Exits with code 3 if the session has expired.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			asJSON, err := cmd.Flags().GetBool("json")
+			if err != nil {
+				return err
+			}
+
+			return a.AuthStatus(cmd.Context(), asJSON)
+		},
+	}
+	authStatusCmd.Flags().Bool("json", false, "Output as JSON")
+
+	authCmd.AddCommand(authMigrateCmd)
+	authCmd.AddCommand(authStatusCmd)
+
+	profileCmd := &cobra.Command{
+		Use:   "profile",
+		Short: "Manage profiles",
+		Long: `Manage profiles.
+
+Every profile keeps the credentials of one account, so several accounts
+or installations can be used side by side. The profile is selected with
+the --profile flag, the TEAMJERK_PROFILE environment variable
+or "teamjerk profile use".`,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.Help()
+		},
+	}
+
+	profileListCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List profiles",
+		Long:  `List profiles. The current one is marked with an asterisk`,
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			current, err := getProfileName(cmd, cfg)
+			if err != nil {
+				return err
+			}
+
+			names, err := profiles.Names()
+			if err != nil {
+				return err
+			}
+
+			for _, name := range names {
+				if name == current {
+					fmt.Println("*", name)
+				} else {
+					fmt.Println(" ", name)
+				}
+			}
+
+			return nil
+		},
+	}
+
+	profileUseCmd := &cobra.Command{
+		Use:   "use <name>",
+		Short: "Set the default profile",
+		Long:  `Set the profile used when neither --profile nor TEAMJERK_PROFILE is given`,
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := args[0]
+
+			store, err := profiles.Get(name)
+			if err != nil {
+				return err
+			}
+			if !store.Exists() {
+				return fmt.Errorf("profile %q does not exist, log in with: teamjerk login --profile %s", name, name)
+			}
+
+			err = config.Update(configFilePath, func(cfg *config.Config) error {
+				cfg.Profile = name
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("Switched to profile %q\n", name)
+
+			return nil
+		},
+	}
+
+	profileRemoveCmd := &cobra.Command{
+		Use:   "remove <name>",
+		Short: "Remove a profile",
+		Long: `Remove the stored credentials of a profile.
+The session is not revoked, use "teamjerk logout --profile <name>" for that`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := args[0]
+
+			store, err := profiles.Get(name)
+			if err != nil {
+				return err
+			}
+			if !store.Exists() {
+				return fmt.Errorf("profile %q does not exist", name)
+			}
+
+			err = store.Remove()
+			if err != nil {
+				return err
+			}
+
+			err = cache.Clear(filepath.Join(cacheDir, name))
+			if err != nil {
+				return err
+			}
+
+			err = config.Update(configFilePath, func(cfg *config.Config) error {
+				if cfg.Profile == name {
+					cfg.Profile = ""
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("Profile %q removed\n", name)
+
+			return nil
+		},
+	}
+
+	profileCmd.AddCommand(profileListCmd)
+	profileCmd.AddCommand(profileUseCmd)
+	profileCmd.AddCommand(profileRemoveCmd)
+
+	cacheCmd := &cobra.Command{
+		Use:   "cache",
+		Short: "Manage the cached profile, projects and tasks",
+		Long: `Manage the cached profile, projects and tasks.
+
+They are cached for the time set with TEAMJERK_CACHE_TTL or "cache_ttl" in the config file (1h by default).
+Use the --refresh flag with any command to fetch them again.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.Help()
+		},
+	}
+
+	cacheClearCmd := &cobra.Command{
+		Use:   "clear",
+		Short: "Remove the cached data of all profiles",
+		Long:  `Remove the cached data of all profiles`,
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := cache.Clear(cacheDir)
+			if err != nil {
+				return err
+			}
+
+			fmt.Println("Cache cleared")
+
+			return nil
+		},
+	}
+
+	cacheCmd.AddCommand(cacheClearCmd)
+
 	versionCmd := &cobra.Command{
 		Use:   "version",
 		Short: "Print the version number of teamjerk",
@@ -256,10 +1363,36 @@ func main() {
 	rootCmd.AddCommand(tasksCmd)
 	rootCmd.AddCommand(logCmd)
 	rootCmd.AddCommand(reportCmd)
+	rootCmd.AddCommand(entriesCmd)
+	rootCmd.AddCommand(historyCmd)
+	rootCmd.AddCommand(undoCmd)
+	rootCmd.AddCommand(authCmd)
+	rootCmd.AddCommand(profileCmd)
+	rootCmd.AddCommand(cacheCmd)
 	rootCmd.AddCommand(versionCmd)
 
-	if err := rootCmd.Execute(); err != nil {
-		log.Fatal(err)
+	// Ctrl-C cancels the requests in flight and the prompts instead of killing the process,
+	// a second Ctrl-C kills it as usual
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
+	// the error is already printed by cobra
+	err = rootCmd.ExecuteContext(ctx)
+	interrupted := ctx.Err() != nil
+	cancelTimeout()
+	stop()
+	closeTrace()
+	if err != nil {
+		if !commandLineValid {
+			os.Exit(exitCodeUsage)
+		}
+		if interrupted {
+			os.Exit(exitCodeInterrupted)
+		}
+		os.Exit(getExitCode(err))
 	}
 
 }
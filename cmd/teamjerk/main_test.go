@@ -0,0 +1,679 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+	// the test binary runs as teamjerk in the time zones the tests need
+	_ "time/tzdata"
+
+	"github.com/harnyk/teamjerk/internal/twfake"
+	"github.com/ysmood/got"
+)
+
+// The end-to-end tests run the test binary itself as teamjerk,
+// against the fake Teamwork server and with a temporary home directory.
+func TestMain(m *testing.M) {
+	if os.Getenv("TEAMJERK_TEST_RUN_MAIN") == "1" {
+		main()
+		os.Exit(0)
+	}
+
+	os.Exit(m.Run())
+}
+
+type cli struct {
+	t    *testing.T
+	home string
+	env  []string
+}
+
+func newCLI(t *testing.T, fake *twfake.Server) *cli {
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	home := t.TempDir()
+
+	return &cli{
+		t:    t,
+		home: home,
+		env: []string{
+			"TEAMJERK_TEST_RUN_MAIN=1",
+			"HOME=" + home,
+			"USERPROFILE=" + home,
+			"TZ=UTC",
+			"TEAMJERK_LAUNCHPAD_URL=" + server.URL,
+		},
+	}
+}
+
+// run runs teamjerk with the arguments and the extra environment variables,
+// returns the standard output and the exit code
+func (c *cli) run(env []string, args ...string) (string, int) {
+	c.t.Helper()
+
+	stdout, _, code := c.runWithStderr(env, args...)
+
+	return stdout, code
+}
+
+// runWithStderr is like run, but returns the standard error as well
+func (c *cli) runWithStderr(env []string, args ...string) (string, string, int) {
+	c.t.Helper()
+
+	cmd := exec.Command(os.Args[0], args...)
+	cmd.Env = append(append([]string{}, c.env...), env...)
+
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	err := cmd.Run()
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		c.t.Logf("teamjerk %s: %s", strings.Join(args, " "), stderr.String())
+		return stdout.String(), stderr.String(), exitErr.ExitCode()
+	}
+	if err != nil {
+		c.t.Fatal(err)
+	}
+
+	return stdout.String(), stderr.String(), 0
+}
+
+func (c *cli) logIn() {
+	c.t.Helper()
+
+	_, code := c.run([]string{
+		"TEAMJERK_EMAIL=" + twfake.Email,
+		"TEAMJERK_PASSWORD=" + twfake.Password,
+	}, "login")
+	got.T(c.t).Eq(code, 0)
+}
+
+func TestLogAndReport(t *testing.T) {
+	g := got.T(t)
+	fake := twfake.NewServer()
+	c := newCLI(t, fake)
+
+	c.logIn()
+
+	out, code := c.run(nil, "whoami")
+	g.Eq(code, 0)
+	g.Has(out, twfake.FirstName)
+
+	out, code = c.run(nil, "tasks")
+	g.Eq(code, 0)
+	g.Has(out, "[ID: 202] Development (Sprint 12, estimate 8h)")
+	g.Has(out, "[ID: 203] Code review (Sprint 12, subtask of Development, #backend)")
+
+	_, code = c.run(nil, "log", "-p", "102", "-t", "202", "-d", "2023-03-01", "-s", "09:00", "-u", "7.5", "-D", "Feature")
+	g.Eq(code, 0)
+	_, code = c.run(nil, "log", "-p", "101", "-t", "201", "-d", "2023-03-02", "-s", "09:00", "-u", "1", "-B")
+	g.Eq(code, 0)
+
+	entries := fake.TimeEntries()
+	g.Len(entries, 2)
+	g.Eq(entries[0].Minutes, uint64(450))
+	g.Eq(entries[0].Description, "Feature")
+	g.Eq(entries[1].IsBillable, false)
+
+	reportFile := filepath.Join(c.home, "report.json")
+	_, code = c.run(nil, "report", "-y", "2023", "-m", "3", "-o", reportFile)
+	g.Eq(code, 0)
+
+	data, err := os.ReadFile(reportFile)
+	g.E(err)
+
+	var report []map[string]interface{}
+	g.E(json.Unmarshal(data, &report))
+	g.Eq(report, []map[string]interface{}{
+		{"date": "2023-03-01", "billable": 7.5, "non_billable": 0.0},
+		{"date": "2023-03-02", "billable": 0.0, "non_billable": 1.0},
+	})
+}
+
+func TestMetadataIsCached(t *testing.T) {
+	g := got.T(t)
+	fake := twfake.NewServer()
+	c := newCLI(t, fake)
+
+	c.logIn()
+
+	logArgs := []string{"log", "-p", "102", "-t", "202", "-d", "2023-03-01", "-s", "09:00", "-u", "1"}
+
+	_, code := c.run(nil, logArgs...)
+	g.Eq(code, 0)
+	_, code = c.run(nil, "report", "-y", "2023", "-m", "3")
+	g.Eq(code, 0)
+	_, code = c.run(nil, "tasks")
+	g.Eq(code, 0)
+	_, code = c.run(nil, "tasks")
+	g.Eq(code, 0)
+
+	g.Eq(fake.Requests("GET", "/me.json"), 1)
+	g.Eq(fake.Requests("GET", "/projects/api/v3/tasks.json"), 1)
+
+	_, code = c.run(nil, append(logArgs, "--refresh")...)
+	g.Eq(code, 0)
+	g.Eq(fake.Requests("GET", "/me.json"), 2)
+
+	_, code = c.run([]string{"TEAMJERK_CACHE_TTL=0s"}, logArgs...)
+	g.Eq(code, 0)
+	g.Eq(fake.Requests("GET", "/me.json"), 3)
+
+	_, code = c.run(nil, "cache", "clear")
+	g.Eq(code, 0)
+	_, code = c.run(nil, "tasks")
+	g.Eq(code, 0)
+	g.Eq(fake.Requests("GET", "/projects/api/v3/tasks.json"), 2)
+}
+
+func TestLogInWithTwoFactor(t *testing.T) {
+	g := got.T(t)
+	fake := twfake.NewServer()
+	fake.OTP = "123456"
+	c := newCLI(t, fake)
+
+	credentials := []string{
+		"TEAMJERK_EMAIL=" + twfake.Email,
+		"TEAMJERK_PASSWORD=" + twfake.Password,
+	}
+
+	_, code := c.run(credentials, "login", "--otp", "000000")
+	g.Eq(code, exitCodeAuth)
+
+	_, code = c.run(credentials, "login", "--otp", "123456")
+	g.Eq(code, 0)
+
+	out, code := c.run(nil, "auth", "status", "--json")
+	g.Eq(code, 0)
+	g.Has(out, `"status": "valid"`)
+}
+
+func TestLogInWithTwoFactorForAccounts(t *testing.T) {
+	g := got.T(t)
+	fake := twfake.NewServer()
+	fake.OTP = "123456"
+	fake.OTPForAccounts = true
+	c := newCLI(t, fake)
+
+	credentials := []string{
+		"TEAMJERK_EMAIL=" + twfake.Email,
+		"TEAMJERK_PASSWORD=" + twfake.Password,
+	}
+
+	_, code := c.run(credentials, "login", "--otp", "000000")
+	g.Eq(code, exitCodeAuth)
+	g.Eq(fake.Requests("POST", "/launchpad/v1/login.json"), 0)
+
+	// the same code verifies the challenges of the accounts and of the installation
+	_, code = c.run(credentials, "login", "--otp", "123456")
+	g.Eq(code, 0)
+	g.Eq(fake.Requests("POST", "/launchpad/v1/login/twofactor.json"), 3)
+
+	out, code := c.run(nil, "auth", "status", "--json")
+	g.Eq(code, 0)
+	g.Has(out, `"status": "valid"`)
+}
+
+func TestAuthMigrate(t *testing.T) {
+	g := got.T(t)
+	fake := twfake.NewServer()
+	c := newCLI(t, fake)
+	passphrase := []string{"TEAMJERK_PASSPHRASE=secret"}
+
+	c.logIn()
+
+	// the environment variable would keep the plaintext store in use
+	_, code := c.run([]string{"TEAMJERK_AUTH_STORE=plain"}, "auth", "migrate")
+	g.Eq(code, 1)
+	g.Eq(fileExists(filepath.Join(c.home, ".teamjerk", "profiles", "default.json")), true)
+
+	out, code := c.run(append(passphrase, "TEAMJERK_AUTH_STORE=encrypted"), "auth", "migrate")
+	g.Eq(code, 0)
+	g.Has(out, "migrated to the encrypted store")
+	g.Eq(fileExists(filepath.Join(c.home, ".teamjerk", "profiles", "default.json")), false)
+
+	out, code = c.run(passphrase, "whoami")
+	g.Eq(code, 0)
+	g.Has(out, twfake.FirstName)
+}
+
+func fileExists(file string) bool {
+	_, err := os.Stat(file)
+	return err == nil
+}
+
+func TestExitCodes(t *testing.T) {
+	g := got.T(t)
+	fake := twfake.NewServer()
+	c := newCLI(t, fake)
+
+	_, code := c.run(nil, "whoami")
+	g.Eq(code, exitCodeAuth)
+
+	_, code = c.run(nil, "whoami", "--bogus")
+	g.Eq(code, exitCodeUsage)
+
+	c.logIn()
+
+	_, code = c.run(nil, "log", "-p", "102", "-t", "999", "-d", "2023-03-01", "-s", "09:00", "-u", "1")
+	g.Eq(code, exitCodeNotFound)
+
+	_, code = c.run(nil, "log", "-p", "102", "-t", "202", "-d", "2023-03-01", "-s", "09:00", "-u", "0.001")
+	g.Eq(code, exitCodeValidation)
+
+	fake.ExpireSessions()
+
+	_, code = c.run(nil, "whoami")
+	g.Eq(code, exitCodeAuth)
+}
+
+func TestInterruptAtPrompt(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Ctrl-C can't be sent to a process on Windows")
+	}
+
+	g := got.T(t)
+	fake := twfake.NewServer()
+	c := newCLI(t, fake)
+
+	c.logIn()
+
+	// the duration is asked for, as it's not given
+	cmd := exec.Command(os.Args[0], "log", "-p", "102", "-t", "202", "-d", "2023-03-01", "-s", "09:00")
+	cmd.Env = c.env
+
+	stdin, err := cmd.StdinPipe()
+	g.E(err)
+	defer stdin.Close()
+	stdout, err := cmd.StdoutPipe()
+	g.E(err)
+	g.E(cmd.Start())
+
+	prompted := make(chan struct{})
+	go func() {
+		output := []byte{}
+		buf := make([]byte, 1024)
+		for {
+			n, err := stdout.Read(buf)
+			output = append(output, buf[:n]...)
+			if bytes.Contains(output, []byte("Duration (in hours)")) {
+				close(prompted)
+				io.Copy(io.Discard, stdout)
+				return
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	select {
+	case <-prompted:
+	case <-time.After(10 * time.Second):
+		cmd.Process.Kill()
+		t.Fatal("the duration has not been asked for")
+	}
+
+	g.E(cmd.Process.Signal(os.Interrupt))
+
+	exited := make(chan error, 1)
+	go func() { exited <- cmd.Wait() }()
+
+	select {
+	case err = <-exited:
+	case <-time.After(10 * time.Second):
+		cmd.Process.Kill()
+		t.Fatal("the prompt has not been interrupted by Ctrl-C")
+	}
+
+	var exitErr *exec.ExitError
+	g.True(errors.As(err, &exitErr))
+	g.Eq(exitErr.ExitCode(), exitCodeInterrupted)
+	g.Len(fake.TimeEntries(), 0)
+}
+
+func TestListEntries(t *testing.T) {
+	g := got.T(t)
+	fake := twfake.NewServer()
+	c := newCLI(t, fake)
+
+	c.logIn()
+
+	_, code := c.run(nil, "log", "-p", "102", "-t", "202", "-d", "2023-03-01", "-s", "09:00", "-u", "7.5", "-D", "Feature")
+	g.Eq(code, 0)
+	_, code = c.run(nil, "log", "-p", "101", "-d", "2023-03-02", "-s", "10:30", "-u", "1", "-B")
+	g.Eq(code, 0)
+	_, code = c.run(nil, "log", "-p", "101", "-t", "201", "-d", "2023-04-03", "-s", "09:00", "-u", "1")
+	g.Eq(code, 0)
+
+	entries := fake.TimeEntries()
+
+	out, code := c.run(nil, "entries", "list", "--from", "2023-03-01", "--to", "2023-03-31", "--json")
+	g.Eq(code, 0)
+
+	var listed []map[string]interface{}
+	g.E(json.Unmarshal([]byte(out), &listed))
+	g.Eq(listed, []map[string]interface{}{
+		{
+			"id": float64(entries[0].ID), "date": "2023-03-01", "start_time": "09:00", "hours": 7.5,
+			"project_id": 102.0, "project": "Website", "task_id": 202.0, "task": "Development",
+			"billable": true, "description": "Feature",
+		},
+		{
+			"id": float64(entries[1].ID), "date": "2023-03-02", "start_time": "10:30", "hours": 1.0,
+			"project_id": 101.0, "project": "Internal",
+			"billable": false, "description": "",
+		},
+	})
+
+	out, code = c.run(nil, "entries", "list", "--from", "2023-03-01", "--to", "2023-04-30", "-t", "201")
+	g.Eq(code, 0)
+	g.Has(out, entries[2].Date)
+	g.Has(out, "Internal / Meetings")
+	g.False(strings.Contains(out, "Website"))
+
+	out, code = c.run(nil, "entries", "list", "--from", "2023-05-01", "--to", "2023-05-31")
+	g.Eq(code, 0)
+	g.Has(out, "No time entries")
+
+	_, code = c.run(nil, "entries", "list", "--from", "2023-05-01", "--to", "2023-04-30")
+	g.Eq(code, 1)
+}
+
+func TestListEntriesInLocalTime(t *testing.T) {
+	g := got.T(t)
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	g.E(err)
+
+	fake := twfake.NewServer()
+	fake.Location = berlin
+	c := newCLI(t, fake)
+	tz := []string{"TZ=Europe/Berlin"}
+
+	c.logIn()
+
+	// it's still the last day of February in UTC
+	_, code := c.run(tz, "log", "-p", "101", "-t", "201", "-d", "2023-03-01", "-s", "00:30", "-u", "1")
+	g.Eq(code, 0)
+
+	out, code := c.run(tz, "entries", "list", "--from", "2023-03-01", "--to", "2023-03-01", "--json")
+	g.Eq(code, 0)
+
+	var listed []map[string]interface{}
+	g.E(json.Unmarshal([]byte(out), &listed))
+	g.Len(listed, 1)
+	g.Eq(listed[0]["date"], "2023-03-01")
+	g.Eq(listed[0]["start_time"], "00:30")
+
+	out, code = c.run(tz, "entries", "list", "--from", "2023-03-01", "--to", "2023-03-01")
+	g.Eq(code, 0)
+	g.Has(out, "2023-03-01")
+	g.Has(out, "00:30")
+}
+
+func TestEditEntry(t *testing.T) {
+	g := got.T(t)
+	fake := twfake.NewServer()
+	c := newCLI(t, fake)
+
+	c.logIn()
+
+	_, code := c.run(nil, "log", "-p", "102", "-t", "202", "-d", "2023-03-01", "-s", "09:00", "-u", "7.5", "-D", "Feature")
+	g.Eq(code, 0)
+	id := strconv.FormatUint(fake.TimeEntries()[0].ID, 10)
+
+	out, code := c.run(nil, "entries", "edit", id, "-t", "203", "-u", "2", "-D", "Review", "-B")
+	g.Eq(code, 0)
+	g.Has(out, "Website / Code review")
+
+	entry := fake.TimeEntries()[0]
+	g.Eq(entry.TaskID, uint64(203))
+	g.Eq(entry.Minutes, uint64(120))
+	g.Eq(entry.Description, "Review")
+	g.Eq(entry.IsBillable, false)
+	g.Eq(entry.Date, "2023-03-01")
+	g.Eq(entry.Time, "09:00:00")
+
+	_, code = c.run(nil, "entries", "edit", id, "-d", "2023-03-02", "-s", "13:30", "-B=false")
+	g.Eq(code, 0)
+
+	entry = fake.TimeEntries()[0]
+	g.Eq(entry.Date, "2023-03-02")
+	g.Eq(entry.Time, "13:30:00")
+	g.Eq(entry.IsBillable, true)
+	g.Eq(entry.Minutes, uint64(120))
+
+	// nothing to change and no terminal to ask
+	_, code = c.run(nil, "entries", "edit", id)
+	g.Eq(code, 1)
+
+	_, code = c.run(nil, "entries", "edit", "999", "-D", "Nothing")
+	g.Eq(code, exitCodeNotFound)
+}
+
+func TestDeleteEntries(t *testing.T) {
+	g := got.T(t)
+	fake := twfake.NewServer()
+	c := newCLI(t, fake)
+
+	c.logIn()
+
+	for _, date := range []string{"2023-03-06", "2023-03-07", "2023-03-08"} {
+		_, code := c.run(nil, "log", "-p", "102", "-t", "202", "-d", date, "-s", "09:00", "-u", "8")
+		g.Eq(code, 0)
+	}
+	_, code := c.run(nil, "log", "-p", "101", "-t", "201", "-d", "2023-03-07", "-s", "17:00", "-u", "1")
+	g.Eq(code, 0)
+
+	entries := fake.TimeEntries()
+	first := strconv.FormatUint(entries[0].ID, 10)
+
+	// no terminal to confirm
+	_, code = c.run(nil, "entries", "delete", first)
+	g.Eq(code, 1)
+	g.Len(fake.TimeEntries(), 4)
+
+	out, code := c.run(nil, "entries", "delete", first, "--yes")
+	g.Eq(code, 0)
+	g.Has(out, "Deleted "+first)
+	g.Len(fake.TimeEntries(), 3)
+
+	out, code = c.run(nil, "entries", "delete", "--from", "2023-03-01", "--to", "2023-03-31", "-t", "202", "-y")
+	g.Eq(code, 0)
+	g.Has(out, "Website / Development")
+	g.False(strings.Contains(out, "Internal"))
+	g.Eq(fake.TimeEntries(), entries[3:])
+
+	_, code = c.run(nil, "entries", "delete")
+	g.Eq(code, 1)
+	_, code = c.run(nil, "entries", "delete", first, "-t", "202")
+	g.Eq(code, 1)
+	_, code = c.run(nil, "entries", "delete", first, "-y")
+	g.Eq(code, exitCodeNotFound)
+}
+
+func TestDeleteEntriesWithExpiredSession(t *testing.T) {
+	g := got.T(t)
+	fake := twfake.NewServer()
+	c := newCLI(t, fake)
+
+	c.logIn()
+
+	for _, date := range []string{"2023-03-06", "2023-03-07", "2023-03-08"} {
+		_, code := c.run(nil, "log", "-p", "102", "-t", "202", "-d", date, "-s", "09:00", "-u", "8")
+		g.Eq(code, 0)
+	}
+
+	ids := []string{}
+	for _, entry := range fake.TimeEntries() {
+		ids = append(ids, strconv.FormatUint(entry.ID, 10))
+	}
+
+	// the session is revoked after the first entry, the rest isn't tried
+	fake.SetExpireSessionsAfter(1)
+
+	out, errOut, code := c.runWithStderr(nil, append([]string{"entries", "delete", "-y"}, ids...)...)
+	g.Eq(code, exitCodeAuth)
+	g.Has(out, "Deleted "+ids[0])
+	g.Has(out, "Failed to delete "+ids[1])
+	g.False(strings.Contains(out, "Failed to delete "+ids[2]))
+	g.Has(errOut, "session expired")
+	g.Has(errOut, "not deleted: "+ids[1]+" "+ids[2])
+	g.Len(fake.TimeEntries(), 2)
+
+	c.logIn()
+
+	_, code = c.run(nil, append([]string{"entries", "delete", "-y"}, ids[1:]...)...)
+	g.Eq(code, 0)
+	g.Len(fake.TimeEntries(), 0)
+}
+
+func TestHistoryAndUndo(t *testing.T) {
+	g := got.T(t)
+	fake := twfake.NewServer()
+	c := newCLI(t, fake)
+
+	c.logIn()
+
+	out, code := c.run(nil, "history")
+	g.Eq(code, 0)
+	g.Has(out, "Nothing has been logged")
+
+	_, code = c.run(nil, "undo", "-y")
+	g.Eq(code, 1)
+
+	out, code = c.run(nil, "log", "-p", "102", "-t", "202", "-d", "2023-03-01", "-s", "09:00", "-u", "7.5", "-D", "Feature")
+	g.Eq(code, 0)
+	entries := fake.TimeEntries()
+	g.Has(out, "Logged, entry ID: "+strconv.FormatUint(entries[0].ID, 10))
+
+	_, code = c.run(nil, "log", "-p", "101", "-d", "2023-03-02", "-s", "09:00", "-u", "1", "-n")
+	g.Eq(code, 0)
+	_, code = c.run(nil, "log", "-p", "101", "-d", "2023-03-02", "-s", "09:00", "-u", "1")
+	g.Eq(code, 0)
+	entries = fake.TimeEntries()
+	g.Len(entries, 2)
+
+	out, code = c.run(nil, "history", "--json")
+	g.Eq(code, 0)
+
+	var records []map[string]interface{}
+	g.E(json.Unmarshal([]byte(out), &records))
+	g.Len(records, 2)
+	g.Eq(records[0]["entry_id"], float64(entries[0].ID))
+	g.Eq(records[0]["profile"], "default")
+	g.Eq(records[1]["request"].(map[string]interface{})["projectId"], 101.0)
+
+	// no terminal to confirm
+	_, code = c.run(nil, "undo")
+	g.Eq(code, 1)
+	g.Len(fake.TimeEntries(), 2)
+
+	_, code = c.run(nil, "undo", "-y")
+	g.Eq(code, 0)
+	g.Eq(fake.TimeEntries(), entries[:1])
+
+	out, code = c.run(nil, "history")
+	g.Eq(code, 0)
+	g.Has(out, "undone")
+
+	// the entry deleted by other means is only marked as undone
+	_, code = c.run(nil, "entries", "delete", strconv.FormatUint(entries[0].ID, 10), "-y")
+	g.Eq(code, 0)
+	out, code = c.run(nil, "undo", "-y")
+	g.Eq(code, 0)
+	g.Has(out, "already been deleted")
+
+	_, code = c.run(nil, "undo", "-y")
+	g.Eq(code, 1)
+}
+
+func TestLogRange(t *testing.T) {
+	g := got.T(t)
+	fake := twfake.NewServer()
+	fake.LockedDates = []string{"2023-03-03"}
+	c := newCLI(t, fake)
+
+	g.E(os.MkdirAll(filepath.Join(c.home, ".teamjerk"), 0700))
+	g.E(os.WriteFile(filepath.Join(c.home, ".teamjerk", "config.json"), []byte(`{"holidays": ["2023-03-08"]}`), 0600))
+
+	c.logIn()
+
+	logArgs := []string{"log", "-p", "102", "-t", "202", "-s", "09:00", "-u", "8"}
+
+	out, code := c.run(nil, append(logArgs, "--from", "2023-03-01", "--to", "2023-03-10", "--dry-run")...)
+	g.Eq(code, 0)
+	g.Has(out, "2023-03-04 Sat  skip, weekend")
+	g.Has(out, "2023-03-08 Wed  skip, holiday")
+	g.Has(out, "2023-03-10 Fri  log")
+	g.Len(fake.TimeEntries(), 0)
+
+	// the locked day fails, the rest is logged
+	out, code = c.run(nil, append(logArgs, "--from", "2023-03-01", "--to", "2023-03-10")...)
+	g.Eq(code, exitCodeValidation)
+	g.Has(out, "2023-03-03 Fri: failed")
+	g.Has(out, "Logged 6 of 7 days")
+
+	dates := []string{}
+	for _, entry := range fake.TimeEntries() {
+		dates = append(dates, entry.Date)
+	}
+	g.Eq(dates, []string{"2023-03-01", "2023-03-02", "2023-03-06", "2023-03-07", "2023-03-09", "2023-03-10"})
+
+	_, code = c.run(nil, append(logArgs, "--week", "-d", "2023-03-15")...)
+	g.Eq(code, 0)
+	_, code = c.run(nil, append(logArgs, "--month", "-d", "2023-04-15")...)
+	g.Eq(code, 0)
+	g.Len(fake.TimeEntries(), 6+5+20)
+
+	_, code = c.run(nil, append(logArgs, "--week", "--month")...)
+	g.Eq(code, 1)
+	_, code = c.run(nil, append(logArgs, "--from", "2023-03-01")...)
+	g.Eq(code, 1)
+}
+
+func TestLogRangeWithExpiredSession(t *testing.T) {
+	g := got.T(t)
+	fake := twfake.NewServer()
+	c := newCLI(t, fake)
+
+	c.logIn()
+	fake.SetExpireSessionsAfter(2)
+
+	logArgs := []string{"log", "-p", "102", "-t", "202", "-s", "09:00", "-u", "8"}
+
+	// the session is revoked after the second day, the rest isn't tried
+	out, errOut, code := c.runWithStderr(nil, append(logArgs, "--from", "2023-03-01", "--to", "2023-03-07")...)
+	g.Eq(code, exitCodeAuth)
+	g.Has(out, "2023-03-03 Fri: failed")
+	g.False(strings.Contains(out, "2023-03-06 Mon: failed"))
+	g.Has(out, "Logged 2 of 5 days")
+	g.Has(errOut, "session expired")
+	g.Has(errOut, "not logged on 2023-03-03, 2023-03-06, 2023-03-07")
+	g.Len(fake.TimeEntries(), 2)
+
+	c.logIn()
+
+	_, code = c.run(nil, append(logArgs, "--from", "2023-03-03", "--to", "2023-03-07")...)
+	g.Eq(code, 0)
+
+	dates := []string{}
+	for _, entry := range fake.TimeEntries() {
+		dates = append(dates, entry.Date)
+	}
+	g.Eq(dates, []string{"2023-03-01", "2023-03-02", "2023-03-03", "2023-03-06", "2023-03-07"})
+}
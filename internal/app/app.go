@@ -1,43 +1,67 @@
 package app
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
+	"net/url"
 	"os"
 	"path/filepath"
 	"sort"
-	"strconv"
+	"strings"
 	"time"
 
 	"github.com/fatih/color"
 	"github.com/harnyk/teamjerk/internal/authstore"
+	"github.com/harnyk/teamjerk/internal/browsercookie"
+	"github.com/harnyk/teamjerk/internal/journal"
 	"github.com/harnyk/teamjerk/internal/twapi"
 	"github.com/olekukonko/tablewriter"
 )
 
 type App interface {
-	LogIn() error
-	WhoAmI() error
-	LogOut() error
-	Projects() error
-	Tasks() error
-	Log(options LogOptions) error
-	Report(beginningOfMonth time.Time, outputFileName string) error
+	LogIn(ctx context.Context, options LoginOptions) error
+	WhoAmI(ctx context.Context) error
+	LogOut(ctx context.Context) error
+	AuthStatus(ctx context.Context, asJSON bool) error
+	Projects(ctx context.Context) error
+	Tasks(ctx context.Context) error
+	Log(ctx context.Context, options LogOptions) error
+	Report(ctx context.Context, beginningOfMonth time.Time, outputFileName string) error
+	ListEntries(ctx context.Context, options EntriesListOptions) error
+	EditEntry(ctx context.Context, entryID twapi.ID, options EntryEditOptions) error
+	DeleteEntries(ctx context.Context, options EntriesDeleteOptions) error
+	History(options HistoryOptions) error
+	Undo(ctx context.Context, yes bool) error
 }
 
+var (
+	ErrNotLoggedIn = errors.New("not logged in")
+	// ErrSessionExpired is returned when the stored credentials
+	// are rejected and the user can't or doesn't want to log in again
+	ErrSessionExpired = errors.New("session expired, run `teamjerk login`")
+)
+
 type app struct {
-	tw    twapi.Client
-	store authstore.AuthStore[twapi.AuthData]
+	tw      twapi.Client
+	store   authstore.AuthStore[twapi.AuthData]
+	profile string
+	cache   *metadataCache
+	journal journal.Journal
 }
 
-func NewApp(tw twapi.Client, store authstore.AuthStore[twapi.AuthData]) App {
-	return &app{tw: tw, store: store}
+func NewApp(tw twapi.Client, store authstore.AuthStore[twapi.AuthData], profile string, cacheOptions CacheOptions, journal journal.Journal) App {
+	return &app{tw: tw, store: store, profile: profile, cache: newMetadataCache(cacheOptions), journal: journal}
 }
 
-func (a *app) Log(options LogOptions) error {
+// withAuth loads the stored credentials and calls fn with them.
+// If the session has expired and the app runs interactively,
+// the user is offered to log in again, after which fn is retried.
+func (a *app) withAuth(ctx context.Context, fn func(auth *twapi.AuthData) error) error {
 	if !a.store.Exists() {
-		return fmt.Errorf("not logged in")
+		return ErrNotLoggedIn
 	}
 
 	auth, err := a.store.Load()
@@ -45,22 +69,74 @@ func (a *app) Log(options LogOptions) error {
 		return err
 	}
 
-	user, err := a.tw.GetMe(auth)
+	err = fn(auth)
+	if !errors.Is(err, twapi.ErrUnauthorized) {
+		return err
+	}
+
+	if !isInteractive() {
+		return ErrSessionExpired
+	}
+	confirmed, err := askConfirmation(ctx, "Session expired. Log in again?")
 	if err != nil {
 		return err
 	}
+	if !confirmed {
+		return ErrSessionExpired
+	}
 
-	userId, err := strconv.ParseUint(user.Person.ID, 10, 64)
+	auth, err = a.reauthenticate(ctx, auth)
 	if err != nil {
 		return err
 	}
 
-	var taskID uint64
-	var projectID uint64
+	return fn(auth)
+}
+
+// reauthenticate runs the login flow for the same installation
+// and with the same scheme as the expired credentials
+func (a *app) reauthenticate(ctx context.Context, expired *twapi.AuthData) (*twapi.AuthData, error) {
+	var auth *twapi.AuthData
+	var whom string
+	var err error
+
+	if expired.IsAPIKey() {
+		auth, whom, err = a.authenticateWithAPIKey(ctx, expired.APIEndPoint)
+	} else {
+		auth, whom, err = a.authenticateWithPassword(ctx, LoginOptions{}, expired.APIEndPoint)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	err = a.store.Save(auth)
+	if err != nil {
+		return nil, err
+	}
+
+	fmt.Printf("Logged in successfully as %s\n", whom)
+
+	return auth, nil
+}
+
+func (a *app) Log(ctx context.Context, options LogOptions) error {
+	return a.withAuth(ctx, func(auth *twapi.AuthData) error {
+		return a.log(ctx, auth, options)
+	})
+}
+
+func (a *app) log(ctx context.Context, auth *twapi.AuthData, options LogOptions) error {
+	user, err := a.getMe(ctx, auth)
+	if err != nil {
+		return err
+	}
+
+	var taskID twapi.ID
+	var projectID twapi.ID
 	var prettyPrint string
 
 	if options.ProjectID == 0 && options.TaskID == 0 {
-		projectID, taskID, prettyPrint, err = a.getProjectAndTaskInteractively(auth)
+		projectID, taskID, prettyPrint, err = a.getProjectAndTaskInteractively(ctx, auth)
 		if err != nil {
 			return err
 		}
@@ -72,7 +148,10 @@ func (a *app) Log(options LogOptions) error {
 
 	var duration time.Duration
 	if options.Duration == 0 {
-		duration = askDuration()
+		duration, err = askDuration(ctx)
+		if err != nil {
+			return err
+		}
 	} else {
 		duration = options.Duration
 	}
@@ -80,68 +159,171 @@ func (a *app) Log(options LogOptions) error {
 
 	var startTime time.Time
 	if options.StartTime.IsZero() {
-		startTime = askStartTime()
+		startTime, err = askStartTime(ctx)
+		if err != nil {
+			return err
+		}
 	} else {
 		startTime = options.StartTime
 	}
 	fmt.Println("Start time:", startTime.Format("15:04:05"))
 
+	newRequest := func(date time.Time) *twapi.LogtimeRequestWithProjectID {
+		return &twapi.LogtimeRequestWithProjectID{
+			LogtimeRequest: twapi.LogtimeRequest{
+				Timelog: twapi.LogtimeTimelog{
+					TaskID:      taskID,
+					Hours:       uint64(duration.Hours()),
+					Minutes:     uint64(duration.Minutes()) % 60,
+					Date:        date.Format("2006-01-02"),
+					Time:        startTime.Format("15:04:05"),
+					Description: options.Description, //TODO: would be nice to take this from the GitHub activity or at least from a command line argument
+					IsBillable:  !options.NonBillable,
+					UserID:      user.Person.ID,
+					TagIDs:      []twapi.ID{},
+				},
+				TimelogOptions: twapi.LogtimeTimelogOptions{
+					MarkTaskComplete: false,
+				},
+			},
+			ProjectID: projectID,
+		}
+	}
+
+	if !options.From.IsZero() {
+		return a.logRange(ctx, auth, planDays(options.From, options.To, options.Holidays), newRequest, options.DryRun)
+	}
+
 	var date time.Time
 	if options.Date.IsZero() {
-		date = askDate()
+		date, err = askDate(ctx)
+		if err != nil {
+			return err
+		}
 	} else {
 		date = options.Date
 	}
 	fmt.Println("Date:", date.Format("2006-01-02"))
 
-	description := options.Description
-
 	if options.DryRun {
 		fmt.Println("Dry run, not logging anything")
 		return nil
 	}
 
-	request := &twapi.LogtimeRequestWithProjectID{
-		LogtimeRequest: twapi.LogtimeRequest{
-			Timelog: twapi.LogtimeTimelog{
-				TaskID:      taskID,
-				Hours:       uint64(duration.Hours()),
-				Minutes:     uint64(duration.Minutes()) % 60,
-				Date:        date.Format("2006-01-02"),
-				Time:        startTime.Format("15:04:05"),
-				Description: description, //TODO: would be nice to take this from the GitHub activity or at least from a command line argument
-				IsBillable:  !options.NonBillable,
-				UserID:      userId,
-				TagIDs:      []uint64{},
-			},
-			TimelogOptions: twapi.LogtimeTimelogOptions{
-				MarkTaskComplete: false,
-			},
-		},
-		ProjectID: projectID,
-	}
-
-	err = a.tw.LogTime(auth, request)
+	timelog, err := a.submitTimelog(ctx, auth, newRequest(date))
 	if err != nil {
 		return err
 	}
 
+	fmt.Println("Logged, entry ID:", timelog.ID)
+
 	return nil
 }
 
-func (a *app) getProjectAndTaskInteractively(auth *twapi.AuthData) (projectID, taskId uint64, prettyPrint string, err error) {
-	projectID = 0
-	taskId = 0
+// logRange logs the time on every planned day which is not skipped,
+// the failed days don't stop the rest
+func (a *app) logRange(
+	ctx context.Context,
+	auth *twapi.AuthData,
+	days []plannedDay,
+	newRequest func(date time.Time) *twapi.LogtimeRequestWithProjectID,
+	dryRun bool,
+) error {
+	fmt.Println("Plan:")
+	workingDays := 0
+	for _, day := range days {
+		if day.Skip != "" {
+			fmt.Printf("  %s  skip, %s\n", day.Format(), day.Skip)
+			continue
+		}
+		fmt.Printf("  %s  log\n", day.Format())
+		workingDays++
+	}
 
-	projects, err := a.tw.GetProjects(auth)
+	if workingDays == 0 {
+		return errors.New("no working days in the range")
+	}
+
+	if dryRun {
+		fmt.Println("Dry run, not logging anything")
+		return nil
+	}
+
+	var firstErr error
+	// notLogged are the dates of the working days which have failed
+	notLogged := []string{}
+
+	for i, day := range days {
+		if day.Skip != "" {
+			continue
+		}
+
+		// don't start the next request after Ctrl-C or the timeout
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		timelog, err := a.submitTimelog(ctx, auth, newRequest(day.Date))
+		if errors.Is(err, twapi.ErrUnauthorized) {
+			// the rest of the days would fail as well. The error doesn't wrap ErrUnauthorized,
+			// so the range isn't logged again after the login, twice on the days logged so far.
+			fmt.Printf("%s: failed: %s\n", day.Format(), err)
+			for _, rest := range days[i:] {
+				if rest.Skip == "" {
+					notLogged = append(notLogged, rest.Date.Format("2006-01-02"))
+				}
+			}
+			fmt.Printf("Logged %d of %d days\n", workingDays-len(notLogged), workingDays)
+
+			return fmt.Errorf("%w, the time is not logged on %s", ErrSessionExpired, strings.Join(notLogged, ", "))
+		}
+		if err != nil {
+			fmt.Printf("%s: failed: %s\n", day.Format(), err)
+			if firstErr == nil {
+				firstErr = err
+			}
+			notLogged = append(notLogged, day.Date.Format("2006-01-02"))
+			continue
+		}
+		fmt.Printf("%s: logged, entry ID: %d\n", day.Format(), timelog.ID)
+	}
+
+	fmt.Printf("Logged %d of %d days\n", workingDays-len(notLogged), workingDays)
+
+	if len(notLogged) > 0 {
+		return fmt.Errorf("failed to log %d of %d days: %w", len(notLogged), workingDays, firstErr)
+	}
+
+	return nil
+}
+
+// submitTimelog logs the time and records it in the journal
+func (a *app) submitTimelog(ctx context.Context, auth *twapi.AuthData, request *twapi.LogtimeRequestWithProjectID) (*twapi.Timelog, error) {
+	timelog, err := a.tw.LogTime(ctx, auth, request)
 	if err != nil {
-		return
+		return nil, err
 	}
 
-	tasks, err := a.tw.GetTasks(auth)
+	a.addToJournal(journal.Record{
+		Time:    time.Now(),
+		Action:  journal.ActionLog,
+		Profile: a.profile,
+		EntryID: timelog.ID,
+		Request: request,
+	})
+
+	return timelog, nil
+}
+
+func (a *app) getProjectAndTaskInteractively(ctx context.Context, auth *twapi.AuthData) (projectID, taskId twapi.ID, prettyPrint string, err error) {
+	projectID = 0
+	taskId = 0
+
+	projects, tasks, wait, err := a.getProjectsAndTasksForPicker(ctx, auth)
 	if err != nil {
 		return
 	}
+	defer wait()
 
 	taskGroups, err := getProjectsAndTasks(projects, tasks)
 	if err != nil {
@@ -160,53 +342,218 @@ func (a *app) getProjectAndTaskInteractively(auth *twapi.AuthData) (projectID, t
 	return
 }
 
-func (a *app) LogIn() error {
-	email, err := askEmail()
+func (a *app) LogIn(ctx context.Context, options LoginOptions) error {
+	var auth *twapi.AuthData
+	var whom string
+	var err error
+
+	switch {
+	case options.APIKey:
+		auth, whom, err = a.authenticateWithAPIKey(ctx, options.URL)
+	case options.Cookie || options.CookieDB != "":
+		auth, whom, err = a.authenticateWithCookie(ctx, options)
+	default:
+		auth, whom, err = a.authenticateWithPassword(ctx, options, "")
+	}
 	if err != nil {
 		return err
 	}
 
-	password, err := askPassword()
+	// the cached data may belong to another user
+	err = a.cache.clear()
 	if err != nil {
 		return err
 	}
 
-	accounts, err := a.tw.GetAccountsToLogIn(email, password)
+	err = a.store.Save(auth)
 	if err != nil {
 		return err
 	}
 
-	account, err := selectAccount(*accounts)
+	fmt.Printf("Logged in successfully as %s\n", whom)
+
+	return nil
+}
+
+// authenticateWithPassword asks for the email and password and logs in.
+// If apiEndPoint is not empty, the account of that installation is used,
+// otherwise the user selects one of the available accounts.
+// Returns the credentials and the description of the logged in user.
+func (a *app) authenticateWithPassword(ctx context.Context, options LoginOptions, apiEndPoint string) (*twapi.AuthData, string, error) {
+	email, err := getEmail(ctx, options)
 	if err != nil {
-		return err
+		return nil, "", err
 	}
 
-	auth, err := a.tw.LogIn(account.Installation.ApiEndPoint, email, password)
+	password, err := getPassword(ctx, options)
 	if err != nil {
-		return err
+		return nil, "", err
+	}
+
+	// the code is asked once, both the accounts and the installation may require it
+	code := options.OTP
+	verifyTwoFactor := func(challenge *twapi.TwoFactorChallenge) (*twapi.AuthData, error) {
+		if code == "" {
+			var err error
+			code, err = askOTP(ctx)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		return a.tw.VerifyTwoFactor(ctx, challenge, code)
 	}
 
-	err = a.store.Save(auth)
+	accounts, err := a.tw.GetAccountsToLogIn(ctx, email, password)
+
+	var twoFactorErr *twapi.TwoFactorRequiredError
+	if errors.As(err, &twoFactorErr) {
+		var session *twapi.AuthData
+		session, err = verifyTwoFactor(twoFactorErr.Challenge)
+		if err == nil {
+			accounts, err = a.tw.GetAccountsWithSession(ctx, session)
+		}
+	}
 	if err != nil {
-		return err
+		return nil, "", err
 	}
 
-	fmt.Printf("Logged in successfully as %s\n", account.String())
+	var account twapi.Account
+	switch {
+	case apiEndPoint != "":
+		account, err = findAccountByAPIEndPoint(*accounts, apiEndPoint)
+	case options.Account != "":
+		account, err = findAccount(*accounts, options.Account)
+	default:
+		account, err = selectAccount(*accounts)
+	}
+	if err != nil {
+		return nil, "", err
+	}
 
-	return nil
+	auth, err := a.tw.LogIn(ctx, account.Installation.ApiEndPoint, email, password)
+	if errors.As(err, &twoFactorErr) {
+		auth, err = verifyTwoFactor(twoFactorErr.Challenge)
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	auth.InstallationID = account.Installation.ID
+	auth.InstallationName = account.Installation.Name
+	auth.Region = account.Installation.Region
+	auth.UserID = account.User.ID
+	auth.LoggedInAt = time.Now()
+
+	return auth, account.String(), nil
 }
 
-func (a *app) WhoAmI() error {
-	if !a.store.Exists() {
-		return fmt.Errorf("not logged in")
+// authenticateWithAPIKey asks for the API key (unless TEAMJERK_API_KEY is set)
+// and validates it against the installation.
+// Returns the credentials and the description of the logged in user.
+func (a *app) authenticateWithAPIKey(ctx context.Context, installationURL string) (*twapi.AuthData, string, error) {
+	var err error
+	if installationURL == "" {
+		installationURL, err = askInstallationURL(ctx)
+		if err != nil {
+			return nil, "", err
+		}
 	}
 
-	auth, err := a.store.Load()
+	apiEndPoint, err := normalizeInstallationURL(installationURL)
 	if err != nil {
-		return err
+		return nil, "", err
+	}
+
+	apiKey := os.Getenv("TEAMJERK_API_KEY")
+	if apiKey == "" {
+		apiKey, err = askAPIKey()
+		if err != nil {
+			return nil, "", err
+		}
+	}
+
+	auth := &twapi.AuthData{
+		Scheme:      twapi.AuthSchemeAPIKey,
+		APIEndPoint: apiEndPoint,
+		Token:       apiKey,
+	}
+
+	return a.validateCredentials(ctx, auth)
+}
+
+// authenticateWithCookie takes the 'tw-auth' cookie pasted by the user
+// or read from the browser cookie database, for users who can only log in with SSO.
+// Returns the credentials and the description of the logged in user.
+func (a *app) authenticateWithCookie(ctx context.Context, options LoginOptions) (*twapi.AuthData, string, error) {
+	var err error
+	installationURL := options.URL
+	if installationURL == "" {
+		installationURL, err = askInstallationURL(ctx)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+
+	apiEndPoint, err := normalizeInstallationURL(installationURL)
+	if err != nil {
+		return nil, "", err
 	}
 
-	res, err := a.tw.GetMe(auth)
+	var token string
+	switch {
+	case options.CookieDB != "":
+		host, _ := url.Parse(apiEndPoint)
+		token, err = browsercookie.Read(options.CookieDB, host.Hostname(), "tw-auth")
+		if errors.Is(err, browsercookie.ErrNotFound) {
+			return nil, "", fmt.Errorf("no 'tw-auth' cookie for %s or its parent domains in %s, log in with the browser first", host.Hostname(), options.CookieDB)
+		}
+	case os.Getenv("TEAMJERK_COOKIE") != "":
+		token = os.Getenv("TEAMJERK_COOKIE")
+	default:
+		token, err = askCookie()
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	auth := &twapi.AuthData{
+		Scheme:      twapi.AuthSchemeCookie,
+		APIEndPoint: apiEndPoint,
+		Token:       token,
+	}
+
+	return a.validateCredentials(ctx, auth)
+}
+
+// validateCredentials checks the credentials obtained bypassing the launchpad
+// (API key, imported cookie) and fills the login metadata from the user profile.
+// Returns the credentials and the description of the logged in user.
+func (a *app) validateCredentials(ctx context.Context, auth *twapi.AuthData) (*twapi.AuthData, string, error) {
+	me, err := a.tw.GetMe(ctx, auth)
+	if err != nil {
+		return nil, "", err
+	}
+
+	auth.InstallationID = me.Person.InstallationID
+	auth.InstallationName = me.Person.CompanyName
+	auth.Region = regionFromAPIEndPoint(auth.APIEndPoint)
+	auth.UserID = me.Person.ID
+	auth.LoggedInAt = time.Now()
+
+	whom := fmt.Sprintf("%s %s @ %s", me.Person.FirstName, me.Person.LastName, me.Person.CompanyName)
+
+	return auth, whom, nil
+}
+
+func (a *app) WhoAmI(ctx context.Context) error {
+	return a.withAuth(ctx, func(auth *twapi.AuthData) error {
+		return a.whoAmI(ctx, auth)
+	})
+}
+
+func (a *app) whoAmI(ctx context.Context, auth *twapi.AuthData) error {
+	res, err := a.tw.GetMe(ctx, auth)
 	if err != nil {
 		return err
 	}
@@ -220,13 +567,15 @@ func (a *app) WhoAmI() error {
 	return nil
 }
 
-func (a *app) LogOut() error {
-	panic("implement me")
-}
+func (a *app) LogOut(ctx context.Context) error {
+	err := a.cache.clear()
+	if err != nil {
+		return err
+	}
 
-func (a *app) Projects() error {
 	if !a.store.Exists() {
-		return fmt.Errorf("not logged in")
+		fmt.Println("Already logged out")
+		return nil
 	}
 
 	auth, err := a.store.Load()
@@ -234,21 +583,45 @@ func (a *app) Projects() error {
 		return err
 	}
 
-	res, err := a.tw.GetProjects(auth)
-	if err != nil {
+	if auth.IsAPIKey() {
+		// there is no session to revoke for an API key
+		return a.removeAPIKey()
+	}
+
+	err = a.tw.LogOut(ctx, auth)
+	if errors.Is(err, twapi.ErrUnauthorized) {
+		fmt.Println("Session has already expired")
+	} else if err != nil {
 		return err
 	}
 
-	for _, project := range res.Projects {
-		fmt.Printf("[ID: %s] %s\n", project.ID, project.Name)
+	err = a.store.Remove()
+	if err != nil {
+		return err
 	}
 
+	fmt.Println("Logged out successfully")
+
 	return nil
 }
 
-func (a *app) Tasks() error {
+type authStatus struct {
+	Profile          string     `json:"profile"`
+	Scheme           string     `json:"scheme"`
+	APIEndPoint      string     `json:"api_endpoint"`
+	InstallationID   twapi.ID   `json:"installation_id,omitempty"`
+	InstallationName string     `json:"installation_name,omitempty"`
+	Region           string     `json:"region,omitempty"`
+	UserID           twapi.ID   `json:"user_id,omitempty"`
+	LoggedInAt       *time.Time `json:"logged_in_at,omitempty"`
+	// Status is one of "valid", "expired" or "unknown"
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+func (a *app) AuthStatus(ctx context.Context, asJSON bool) error {
 	if !a.store.Exists() {
-		return fmt.Errorf("not logged in")
+		return ErrNotLoggedIn
 	}
 
 	auth, err := a.store.Load()
@@ -256,7 +629,123 @@ func (a *app) Tasks() error {
 		return err
 	}
 
-	res, err := a.tw.GetTasks(auth)
+	status := authStatus{
+		Profile:          a.profile,
+		Scheme:           auth.Scheme,
+		APIEndPoint:      auth.APIEndPoint,
+		InstallationID:   auth.InstallationID,
+		InstallationName: auth.InstallationName,
+		Region:           auth.Region,
+		UserID:           auth.UserID,
+	}
+	if status.Scheme == "" {
+		status.Scheme = twapi.AuthSchemeCookie
+	}
+	if !auth.LoggedInAt.IsZero() {
+		status.LoggedInAt = &auth.LoggedInAt
+	}
+
+	_, checkErr := a.tw.GetMe(ctx, auth)
+	switch {
+	case checkErr == nil:
+		status.Status = "valid"
+	case errors.Is(checkErr, twapi.ErrUnauthorized):
+		status.Status = "expired"
+	default:
+		status.Status = "unknown"
+		status.Error = checkErr.Error()
+	}
+
+	if asJSON {
+		jsonData, err := json.MarshalIndent(status, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(jsonData))
+	} else {
+		renderAuthStatus(status)
+	}
+
+	if status.Status == "expired" {
+		return ErrSessionExpired
+	}
+
+	return checkErr
+}
+
+func renderAuthStatus(status authStatus) {
+	orDash := func(v interface{}) string {
+		s := fmt.Sprint(v)
+		if s == "" || s == "0" {
+			return "-"
+		}
+		return s
+	}
+
+	loggedInAt := "-"
+	if status.LoggedInAt != nil {
+		loggedInAt = status.LoggedInAt.Local().Format("2006-01-02 15:04:05")
+	}
+
+	var statusColor func(a ...interface{}) string
+	switch status.Status {
+	case "valid":
+		statusColor = color.New(color.FgGreen).SprintFunc()
+	case "expired":
+		statusColor = color.New(color.FgRed).SprintFunc()
+	default:
+		statusColor = color.New(color.FgYellow).SprintFunc()
+	}
+
+	fmt.Println("Profile      :", status.Profile)
+	fmt.Println("Scheme       :", status.Scheme)
+	fmt.Println("API endpoint :", status.APIEndPoint)
+	fmt.Println("Installation :", orDash(status.InstallationName), "(ID:", orDash(status.InstallationID)+")")
+	fmt.Println("Region       :", orDash(status.Region))
+	fmt.Println("User ID      :", orDash(status.UserID))
+	fmt.Println("Logged in at :", loggedInAt)
+	fmt.Println("Status       :", statusColor(status.Status))
+}
+
+func (a *app) removeAPIKey() error {
+	err := a.store.Remove()
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("Logged out successfully")
+	fmt.Println("The API key itself is still valid, revoke it in the Teamwork profile settings if needed")
+
+	return nil
+}
+
+func (a *app) Projects(ctx context.Context) error {
+	return a.withAuth(ctx, func(auth *twapi.AuthData) error {
+		return a.projects(ctx, auth)
+	})
+}
+
+func (a *app) projects(ctx context.Context, auth *twapi.AuthData) error {
+	res, err := a.getProjects(ctx, auth)
+	if err != nil {
+		return err
+	}
+
+	for _, project := range res.Projects {
+		fmt.Printf("[ID: %d] %s\n", project.ID, project.Name)
+	}
+
+	return nil
+}
+
+func (a *app) Tasks(ctx context.Context) error {
+	return a.withAuth(ctx, func(auth *twapi.AuthData) error {
+		return a.tasks(ctx, auth)
+	})
+}
+
+func (a *app) tasks(ctx context.Context, auth *twapi.AuthData) error {
+	res, err := a.getTasks(ctx, auth)
 	if err != nil {
 		return err
 	}
@@ -266,7 +755,7 @@ func (a *app) Tasks() error {
 	for _, taskGroup := range taskGroups {
 		fmt.Printf("[ProjectID: %d] %s\n", taskGroup.Project.ID, taskGroup.Project.Name)
 		for _, task := range taskGroup.Tasks {
-			fmt.Printf("  [ID: %d] %s\n", task.ID, task.Content)
+			fmt.Printf("  [ID: %d] %s\n", task.ID, describeTask(task))
 		}
 	}
 
@@ -279,17 +768,19 @@ type chartSeries struct {
 	NonBillableTime time.Duration `json:"nonBillable"`
 }
 
-func (a *app) Report(beginningOfMonth time.Time, outputFileName string) error {
-	if !a.store.Exists() {
-		return fmt.Errorf("not logged in")
-	}
+func (a *app) Report(ctx context.Context, beginningOfMonth time.Time, outputFileName string) error {
+	return a.withAuth(ctx, func(auth *twapi.AuthData) error {
+		return a.report(ctx, auth, beginningOfMonth, outputFileName)
+	})
+}
 
-	auth, err := a.store.Load()
+func (a *app) report(ctx context.Context, auth *twapi.AuthData, beginningOfMonth time.Time, outputFileName string) error {
+	user, err := a.getMe(ctx, auth)
 	if err != nil {
 		return err
 	}
 
-	res, err := a.tw.GetLoggedTime(auth, beginningOfMonth)
+	res, err := a.tw.GetLoggedTime(ctx, auth, user.Person.ID, beginningOfMonth)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,95 @@
+package app
+
+import (
+	"context"
+	"path/filepath"
+
+	"github.com/harnyk/teamjerk/internal/cache"
+	"github.com/harnyk/teamjerk/internal/twapi"
+)
+
+// metadataCache keeps the data needed by most commands,
+// which rarely changes on the Teamwork side
+type metadataCache struct {
+	dir      string
+	me       cache.Item[twapi.ProfileResponse]
+	projects cache.Item[twapi.ProjectsResponse]
+	tasks    cache.Item[twapi.TasksResponse]
+}
+
+func newMetadataCache(options CacheOptions) *metadataCache {
+	// the projects and the tasks cached before API v3 was used are fetched again
+	return &metadataCache{
+		dir:      options.Dir,
+		me:       cache.NewItem[twapi.ProfileResponse](filepath.Join(options.Dir, "me.json"), options.TTL, options.Refresh),
+		projects: cache.NewItem[twapi.ProjectsResponse](filepath.Join(options.Dir, "projects.json"), options.TTL, options.Refresh, cache.Invalidate),
+		tasks:    cache.NewItem[twapi.TasksResponse](filepath.Join(options.Dir, "tasks.json"), options.TTL, options.Refresh, cache.Invalidate),
+	}
+}
+
+// clear removes the cached data, e.g. when another user logs in with the profile
+func (c *metadataCache) clear() error {
+	return cache.Clear(c.dir)
+}
+
+func (a *app) getMe(ctx context.Context, auth *twapi.AuthData) (*twapi.ProfileResponse, error) {
+	return a.cache.me.Get(func() (*twapi.ProfileResponse, error) {
+		return a.tw.GetMe(ctx, auth)
+	})
+}
+
+func (a *app) getProjects(ctx context.Context, auth *twapi.AuthData) (*twapi.ProjectsResponse, error) {
+	return a.cache.projects.Get(func() (*twapi.ProjectsResponse, error) {
+		return a.tw.GetProjects(ctx, auth)
+	})
+}
+
+func (a *app) getTasks(ctx context.Context, auth *twapi.AuthData) (*twapi.TasksResponse, error) {
+	return a.cache.tasks.Get(func() (*twapi.TasksResponse, error) {
+		return a.tw.GetTasks(ctx, auth)
+	})
+}
+
+// getProjectsAndTasksForPicker returns the cached projects and tasks even if they have expired,
+// so the picker opens instantly. The expired ones are fetched in the background
+// and cached for the next time, wait blocks until it is done.
+// Without the cached data, they are fetched right away.
+func (a *app) getProjectsAndTasksForPicker(ctx context.Context, auth *twapi.AuthData) (
+	projects *twapi.ProjectsResponse, tasks *twapi.TasksResponse, wait func(), err error,
+) {
+	projects, projectsFresh := a.cache.projects.Peek()
+	tasks, tasksFresh := a.cache.tasks.Peek()
+
+	if projects == nil || tasks == nil {
+		projects, err = a.getProjects(ctx, auth)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+
+		tasks, err = a.getTasks(ctx, auth)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+
+		return projects, tasks, func() {}, nil
+	}
+
+	if projectsFresh && tasksFresh {
+		return projects, tasks, func() {}, nil
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		// the cached data is already shown, so the errors are ignored
+		if freshProjects, err := a.tw.GetProjects(ctx, auth); err == nil {
+			_ = a.cache.projects.Set(freshProjects)
+		}
+		if freshTasks, err := a.tw.GetTasks(ctx, auth); err == nil {
+			_ = a.cache.tasks.Set(freshTasks)
+		}
+	}()
+
+	return projects, tasks, func() { <-done }, nil
+}
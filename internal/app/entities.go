@@ -1,14 +1,109 @@
 package app
 
-import "time"
+import (
+	"time"
+
+	"github.com/harnyk/teamjerk/internal/twapi"
+)
 
 type LogOptions struct {
 	DryRun      bool
 	NonBillable bool
-	ProjectID   uint64
-	TaskID      uint64
+	ProjectID   twapi.ID
+	TaskID      twapi.ID
 	Date        time.Time
 	StartTime   time.Time
 	Duration    time.Duration
 	Description string
+
+	// From and To are the first and the last day of the range to log, inclusive.
+	// If set, the same time is logged on every working day of the range instead of Date.
+	From time.Time
+	To   time.Time
+	// Holidays are skipped in the range, as well as the weekends
+	Holidays []time.Time
+}
+
+// EntriesFilter selects the time entries, the zero fields select all of them
+type EntriesFilter struct {
+	// From and To are the first and the last day of the range, inclusive
+	From time.Time
+	To   time.Time
+	// UserID is the user who logged the time, the current user if zero
+	UserID    twapi.ID
+	ProjectID twapi.ID
+	TaskID    twapi.ID
+}
+
+type EntriesListOptions struct {
+	EntriesFilter
+	// JSON makes the entries printed as JSON instead of a table
+	JSON bool
+}
+
+// EntryEditOptions are the changes of a time entry, the nil fields are kept.
+// If all of them are nil, the entry is edited interactively.
+type EntryEditOptions struct {
+	TaskID      *twapi.ID
+	Date        *time.Time
+	StartTime   *time.Time
+	Duration    *time.Duration
+	Description *string
+	NonBillable *bool
+}
+
+func (o *EntryEditOptions) isEmpty() bool {
+	return *o == EntryEditOptions{}
+}
+
+type EntriesDeleteOptions struct {
+	// IDs are the entries to delete.
+	// If empty, the entries selected by the filter are deleted.
+	IDs []twapi.ID
+	EntriesFilter
+	// Yes skips the confirmation
+	Yes bool
+}
+
+type HistoryOptions struct {
+	// Limit is the number of the most recent entries shown, all if zero
+	Limit int
+	// JSON makes the journal records printed as JSON instead of a table
+	JSON bool
+}
+
+type LoginOptions struct {
+	// Email is used instead of TEAMJERK_EMAIL or asking interactively
+	Email string
+	// PasswordStdin makes the password to be read from the standard input
+	PasswordStdin bool
+	// PasswordCommand is a shell command printing the password,
+	// used if neither PasswordStdin nor TEAMJERK_PASSWORD is set
+	PasswordCommand string
+	// Account is the installation ID or name to log in to
+	// when the user has several accounts
+	Account string
+
+	// APIKey enables logging in with a personal API key instead of email and password
+	APIKey bool
+	// Cookie enables logging in with a 'tw-auth' cookie copied from the browser
+	Cookie bool
+	// CookieDB is the path to the Firefox or Chromium cookie database
+	// to read the 'tw-auth' cookie from
+	CookieDB string
+	// URL is the installation URL, e.g. https://example.teamwork.com/
+	URL string
+	// OTP is the two-factor authentication code.
+	// If empty and the account requires it, the code is asked interactively.
+	OTP string
+}
+
+type CacheOptions struct {
+	// Dir is the directory of the cache of the profile
+	Dir string
+	// TTL is how long the cached profile, projects and tasks are used
+	// before they are fetched again
+	TTL time.Duration
+	// Refresh makes the cached data ignored and fetched again
+	Refresh bool
 }
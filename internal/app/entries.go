@@ -0,0 +1,404 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/harnyk/teamjerk/internal/twapi"
+	"github.com/olekukonko/tablewriter"
+)
+
+func (a *app) ListEntries(ctx context.Context, options EntriesListOptions) error {
+	return a.withAuth(ctx, func(auth *twapi.AuthData) error {
+		return a.listEntries(ctx, auth, options)
+	})
+}
+
+func (a *app) listEntries(ctx context.Context, auth *twapi.AuthData, options EntriesListOptions) error {
+	filter, err := a.getTimelogsFilter(ctx, auth, options.EntriesFilter)
+	if err != nil {
+		return err
+	}
+
+	timelogs, err := a.tw.GetTimelogs(ctx, auth, filter)
+	if err != nil {
+		return err
+	}
+
+	if options.JSON {
+		return renderEntriesAsJSON(timelogs)
+	}
+
+	if len(timelogs) == 0 {
+		fmt.Println("No time entries")
+		return nil
+	}
+
+	renderEntriesAsTable(timelogs)
+
+	return nil
+}
+
+func (a *app) EditEntry(ctx context.Context, entryID twapi.ID, options EntryEditOptions) error {
+	return a.withAuth(ctx, func(auth *twapi.AuthData) error {
+		return a.editEntry(ctx, auth, entryID, options)
+	})
+}
+
+func (a *app) editEntry(ctx context.Context, auth *twapi.AuthData, entryID twapi.ID, options EntryEditOptions) error {
+	if options.isEmpty() {
+		if !isInteractive() {
+			return errors.New("nothing to change, use the flags to set the new values")
+		}
+
+		timelog, err := a.tw.GetTimelog(ctx, auth, entryID)
+		if err != nil {
+			return err
+		}
+
+		options, err = askEntryChanges(timelog)
+		if err != nil {
+			return err
+		}
+		if options.isEmpty() {
+			fmt.Println("Nothing changed")
+			return nil
+		}
+	}
+
+	updated, err := a.tw.UpdateTimelog(ctx, auth, entryID, newTimelogUpdate(options))
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("Entry updated:")
+	renderEntriesAsTable([]twapi.Timelog{*updated})
+
+	return nil
+}
+
+// newTimelogUpdate returns the update of the fields set in the options
+func newTimelogUpdate(options EntryEditOptions) *twapi.TimelogUpdate {
+	update := &twapi.TimelogUpdate{TaskID: options.TaskID, Description: options.Description}
+
+	if options.Date != nil {
+		date := options.Date.Format("2006-01-02")
+		update.Date = &date
+	}
+	if options.StartTime != nil {
+		startTime := options.StartTime.Format("15:04:05")
+		update.Time = &startTime
+	}
+	if options.Duration != nil {
+		hours := uint64(options.Duration.Hours())
+		minutes := uint64(options.Duration.Minutes()) % 60
+		update.Hours = &hours
+		update.Minutes = &minutes
+	}
+	if options.NonBillable != nil {
+		billable := !*options.NonBillable
+		update.IsBillable = &billable
+	}
+
+	return update
+}
+
+// askEntryChanges asks for the new values of the entry, pre-filled with the current ones,
+// and returns the changed ones
+func askEntryChanges(timelog *twapi.Timelog) (EntryEditOptions, error) {
+	options := EntryEditOptions{}
+
+	fmt.Println("Editing entry", timelog.ID, "on", describeTarget(*timelog))
+
+	// the time is returned in UTC, while it's logged in the local time
+	timeLogged := timelog.TimeLogged.Local()
+
+	taskS, err := askWithDefault("Task ID", timelog.TaskID.String(), func(s string) error {
+		_, err := twapi.ParseID(s)
+		return err
+	})
+	if err != nil {
+		return options, err
+	}
+	if taskID, _ := twapi.ParseID(taskS); taskID != timelog.TaskID {
+		options.TaskID = &taskID
+	}
+
+	dateS, err := askWithDefault("Date (YYYY-MM-DD)", timeLogged.Format("2006-01-02"), func(s string) error {
+		_, err := time.Parse("2006-01-02", s)
+		return err
+	})
+	if err != nil {
+		return options, err
+	}
+	if dateS != timeLogged.Format("2006-01-02") {
+		date, _ := time.Parse("2006-01-02", dateS)
+		options.Date = &date
+	}
+
+	startTimeS, err := askWithDefault("Start time (HH:mm)", timeLogged.Format("15:04"), func(s string) error {
+		_, err := time.Parse("15:04", s)
+		return err
+	})
+	if err != nil {
+		return options, err
+	}
+	if startTimeS != timeLogged.Format("15:04") {
+		startTime, _ := time.Parse("15:04", startTimeS)
+		options.StartTime = &startTime
+	}
+
+	currentHours := strconv.FormatFloat(timelog.Duration().Hours(), 'f', -1, 64)
+	hoursS, err := askWithDefault("Duration (in hours)", currentHours, func(s string) error {
+		hours, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return err
+		}
+		if hours <= 0 || hours > 24 {
+			return errors.New("duration must be between 0 and 24 hours")
+		}
+		return nil
+	})
+	if err != nil {
+		return options, err
+	}
+	if hoursS != currentHours {
+		hours, _ := strconv.ParseFloat(hoursS, 64)
+		duration := time.Duration(hours * float64(time.Hour))
+		options.Duration = &duration
+	}
+
+	description, err := askWithDefault("Description", timelog.Description, nil)
+	if err != nil {
+		return options, err
+	}
+	if description != timelog.Description {
+		options.Description = &description
+	}
+
+	currentBillable := "n"
+	if timelog.IsBillable {
+		currentBillable = "y"
+	}
+	billableS, err := askWithDefault("Billable (y/n)", currentBillable, func(s string) error {
+		if s != "y" && s != "n" {
+			return errors.New("answer y or n")
+		}
+		return nil
+	})
+	if err != nil {
+		return options, err
+	}
+	if billableS != currentBillable {
+		nonBillable := billableS == "n"
+		options.NonBillable = &nonBillable
+	}
+
+	return options, nil
+}
+
+func (a *app) DeleteEntries(ctx context.Context, options EntriesDeleteOptions) error {
+	return a.withAuth(ctx, func(auth *twapi.AuthData) error {
+		return a.deleteEntries(ctx, auth, options)
+	})
+}
+
+func (a *app) deleteEntries(ctx context.Context, auth *twapi.AuthData, options EntriesDeleteOptions) error {
+	timelogs := []twapi.Timelog{}
+
+	if len(options.IDs) > 0 {
+		for _, id := range options.IDs {
+			timelog, err := a.tw.GetTimelog(ctx, auth, id)
+			if err != nil {
+				return err
+			}
+			timelogs = append(timelogs, *timelog)
+		}
+	} else {
+		filter, err := a.getTimelogsFilter(ctx, auth, options.EntriesFilter)
+		if err != nil {
+			return err
+		}
+
+		timelogs, err = a.tw.GetTimelogs(ctx, auth, filter)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(timelogs) == 0 {
+		fmt.Println("No time entries to delete")
+		return nil
+	}
+
+	fmt.Println("The entries to delete:")
+	renderEntriesAsTable(timelogs)
+
+	if !options.Yes {
+		if !isInteractive() {
+			return errors.New("deleting needs a confirmation, use --yes to skip it")
+		}
+		confirmed, err := askDangerousConfirmation(ctx, fmt.Sprintf("Delete %d entries?", len(timelogs)))
+		if err != nil {
+			return err
+		}
+		if !confirmed {
+			fmt.Println("Nothing deleted")
+			return nil
+		}
+	}
+
+	// the entries are deleted one by one, the failures don't stop the rest
+	var firstErr error
+	// notDeleted are the IDs of the entries which have failed
+	notDeleted := []string{}
+
+	for i, timelog := range timelogs {
+		// don't start the next request after Ctrl-C or the timeout
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		err := a.tw.DeleteTimelog(ctx, auth, timelog.ID)
+		if errors.Is(err, twapi.ErrUnauthorized) {
+			// the rest of the entries would fail as well. The error doesn't wrap ErrUnauthorized,
+			// so the deletion isn't run again after the login, failing on the entries deleted so far.
+			fmt.Printf("Failed to delete %d: %s\n", timelog.ID, err)
+			for _, rest := range timelogs[i:] {
+				notDeleted = append(notDeleted, rest.ID.String())
+			}
+
+			return fmt.Errorf("%w, the entries are not deleted: %s", ErrSessionExpired, strings.Join(notDeleted, " "))
+		}
+		if err != nil {
+			fmt.Printf("Failed to delete %d: %s\n", timelog.ID, err)
+			if firstErr == nil {
+				firstErr = err
+			}
+			notDeleted = append(notDeleted, timelog.ID.String())
+			continue
+		}
+		fmt.Printf("Deleted %d\n", timelog.ID)
+	}
+
+	if len(notDeleted) > 0 {
+		return fmt.Errorf("failed to delete %d of %d entries: %w", len(notDeleted), len(timelogs), firstErr)
+	}
+
+	return nil
+}
+
+// getTimelogsFilter returns the filter of the entries of the current user,
+// unless another user is given
+func (a *app) getTimelogsFilter(ctx context.Context, auth *twapi.AuthData, filter EntriesFilter) (twapi.TimelogsFilter, error) {
+	userID := filter.UserID
+	if userID == 0 {
+		user, err := a.getMe(ctx, auth)
+		if err != nil {
+			return twapi.TimelogsFilter{}, err
+		}
+		userID = user.Person.ID
+	}
+
+	return twapi.TimelogsFilter{
+		From:      filter.From,
+		To:        filter.To,
+		UserID:    userID,
+		ProjectID: filter.ProjectID,
+		TaskID:    filter.TaskID,
+	}, nil
+}
+
+func renderEntriesAsJSON(timelogs []twapi.Timelog) error {
+	type entryJSON struct {
+		ID          twapi.ID `json:"id"`
+		Date        string   `json:"date"`
+		StartTime   string   `json:"start_time"`
+		Hours       float64  `json:"hours"`
+		ProjectID   twapi.ID `json:"project_id"`
+		Project     string   `json:"project"`
+		TaskID      twapi.ID `json:"task_id,omitempty"`
+		Task        string   `json:"task,omitempty"`
+		Billable    bool     `json:"billable"`
+		Description string   `json:"description"`
+	}
+
+	entries := []entryJSON{}
+
+	for _, timelog := range timelogs {
+		entries = append(entries, entryJSON{
+			ID:          timelog.ID,
+			Date:        timelog.TimeLogged.Local().Format("2006-01-02"),
+			StartTime:   timelog.TimeLogged.Local().Format("15:04"),
+			Hours:       timelog.Duration().Hours(),
+			ProjectID:   timelog.ProjectID,
+			Project:     timelog.ProjectName,
+			TaskID:      timelog.TaskID,
+			Task:        timelog.TaskName,
+			Billable:    timelog.IsBillable,
+			Description: timelog.Description,
+		})
+	}
+
+	jsonData, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(jsonData))
+
+	return nil
+}
+
+func renderEntriesAsTable(timelogs []twapi.Timelog) {
+	tableRows := [][]string{}
+
+	var totalTime time.Duration
+
+	for _, timelog := range timelogs {
+		totalTime += timelog.Duration()
+
+		billable := "no"
+		if timelog.IsBillable {
+			billable = "yes"
+		}
+
+		tableRows = append(tableRows, []string{
+			timelog.ID.String(),
+			timelog.TimeLogged.Local().Format("2006-01-02"),
+			timelog.TimeLogged.Local().Format("15:04"),
+			formatDuration(timelog.Duration()),
+			describeTarget(timelog),
+			billable,
+			timelog.Description,
+		})
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeaderAlignment(tablewriter.ALIGN_CENTER)
+	table.SetAutoWrapText(false)
+
+	table.SetHeader([]string{"ID", "Date", "Start", "Duration", "Project / Task", "Billable", "Description"})
+	table.AppendBulk(tableRows)
+	if len(timelogs) > 1 {
+		// the blank footer cells are spaces, as the empty ones are merged by tablewriter
+		table.SetFooter([]string{"Total", " ", " ", formatDuration(totalTime), " ", " ", " "})
+	}
+
+	table.Render()
+}
+
+// describeTarget returns the project and the task the time is logged on
+func describeTarget(timelog twapi.Timelog) string {
+	target := []string{timelog.ProjectName}
+	if timelog.TaskID != 0 {
+		target = append(target, timelog.TaskName)
+	}
+
+	return strings.Join(target, " / ")
+}
@@ -0,0 +1,151 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/harnyk/teamjerk/internal/journal"
+	"github.com/harnyk/teamjerk/internal/twapi"
+	"github.com/olekukonko/tablewriter"
+)
+
+// addToJournal records the action in the journal.
+// The action has already been done on the Teamwork side,
+// so failing to record it is reported but is not an error.
+func (a *app) addToJournal(record journal.Record) {
+	if err := a.journal.Append(record); err != nil {
+		fmt.Fprintln(os.Stderr, "Warning: failed to write the journal:", err)
+	}
+}
+
+func (a *app) History(options HistoryOptions) error {
+	records, err := a.journal.Records()
+	if err != nil {
+		return err
+	}
+
+	logged := []journal.Record{}
+	for _, record := range records {
+		if record.Action == journal.ActionLog && record.Profile == a.profile {
+			logged = append(logged, record)
+		}
+	}
+	if options.Limit > 0 && len(logged) > options.Limit {
+		logged = logged[len(logged)-options.Limit:]
+	}
+
+	if options.JSON {
+		jsonData, err := json.MarshalIndent(logged, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(jsonData))
+		return nil
+	}
+
+	if len(logged) == 0 {
+		fmt.Println("Nothing has been logged with this profile yet")
+		return nil
+	}
+
+	renderHistoryAsTable(records, logged)
+
+	return nil
+}
+
+func renderHistoryAsTable(records, logged []journal.Record) {
+	tableRows := [][]string{}
+
+	for _, record := range logged {
+		timelog := record.Request.Timelog
+		duration := time.Duration(timelog.Hours)*time.Hour + time.Duration(timelog.Minutes)*time.Minute
+
+		target := "project " + record.Request.ProjectID.String()
+		if timelog.TaskID != 0 {
+			target = "task " + timelog.TaskID.String()
+		}
+
+		status := ""
+		if journal.IsUndone(records, record) {
+			status = "undone"
+		}
+
+		tableRows = append(tableRows, []string{
+			record.Time.Local().Format("2006-01-02 15:04"),
+			record.EntryID.String(),
+			timelog.Date,
+			formatDuration(duration),
+			target,
+			timelog.Description,
+			status,
+		})
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeaderAlignment(tablewriter.ALIGN_CENTER)
+	table.SetAutoWrapText(false)
+
+	table.SetHeader([]string{"Logged at", "Entry ID", "Date", "Duration", "Logged on", "Description", "Status"})
+	table.AppendBulk(tableRows)
+
+	table.Render()
+}
+
+func (a *app) Undo(ctx context.Context, yes bool) error {
+	return a.withAuth(ctx, func(auth *twapi.AuthData) error {
+		return a.undo(ctx, auth, yes)
+	})
+}
+
+// undo deletes the entry logged last with the profile
+func (a *app) undo(ctx context.Context, auth *twapi.AuthData, yes bool) error {
+	records, err := a.journal.Records()
+	if err != nil {
+		return err
+	}
+
+	last, ok := journal.LastLogged(records, a.profile)
+	if !ok {
+		return errors.New("nothing to undo")
+	}
+
+	fmt.Println("The last logged entry:")
+	renderHistoryAsTable(records, []journal.Record{last})
+
+	if !yes {
+		if !isInteractive() {
+			return errors.New("undoing needs a confirmation, use --yes to skip it")
+		}
+		confirmed, err := askDangerousConfirmation(ctx, "Delete it?")
+		if err != nil {
+			return err
+		}
+		if !confirmed {
+			fmt.Println("Nothing deleted")
+			return nil
+		}
+	}
+
+	err = a.tw.DeleteTimelog(ctx, auth, last.EntryID)
+	switch {
+	case errors.Is(err, twapi.ErrNotFound):
+		fmt.Printf("Entry %d has already been deleted\n", last.EntryID)
+	case err != nil:
+		return err
+	default:
+		fmt.Printf("Deleted %d\n", last.EntryID)
+	}
+
+	a.addToJournal(journal.Record{
+		Time:    time.Now(),
+		Action:  journal.ActionUndo,
+		Profile: a.profile,
+		EntryID: last.EntryID,
+	})
+
+	return nil
+}
@@ -1,9 +1,16 @@
 package app
 
 import (
+	"bufio"
+	"bytes"
+	"context"
 	"fmt"
+	"net/url"
 	"os"
+	"os/exec"
+	"runtime"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/fatih/color"
@@ -12,10 +19,11 @@ import (
 	"github.com/harnyk/teamjerk/internal/twapi"
 	"github.com/howeyc/gopass"
 	"github.com/manifoldco/promptui"
+	"golang.org/x/term"
 )
 
 type timelogTargetSelection struct {
-	Project twapi.TaskProject
+	Project twapi.Project
 	Task    twapi.Task
 }
 
@@ -24,7 +32,33 @@ func (tts *timelogTargetSelection) Serialize() string {
 }
 
 func (tts *timelogTargetSelection) PrettyPrint() string {
-	return fmt.Sprintf("[%s] %s: %s", tts.Serialize(), tts.Project.Name, tts.Task.Content)
+	return fmt.Sprintf("[%s] %s: %s", tts.Serialize(), tts.Project.Name, tts.Task.Name)
+}
+
+// describeTask returns the task name followed by its tasklist,
+// parent task, estimate and tags, if any
+func describeTask(task twapi.Task) string {
+	details := []string{}
+
+	if task.TasklistName != "" {
+		details = append(details, task.TasklistName)
+	}
+	if task.ParentTaskName != "" {
+		details = append(details, "subtask of "+task.ParentTaskName)
+	}
+	if task.EstimateMinutes > 0 {
+		estimate := time.Duration(task.EstimateMinutes) * time.Minute
+		details = append(details, "estimate "+strconv.FormatFloat(estimate.Hours(), 'f', -1, 64)+"h")
+	}
+	for _, tag := range task.Tags {
+		details = append(details, "#"+tag.Name)
+	}
+
+	if len(details) == 0 {
+		return task.Name
+	}
+
+	return fmt.Sprintf("%s (%s)", task.Name, strings.Join(details, ", "))
 }
 
 // getProjectsAndTasks returns a slice of twapi.TasksGroup
@@ -38,9 +72,8 @@ func getProjectsAndTasks(
 	taskGroups := tasks.GroupByProject()
 
 	projectsIdsFromTaskGroups, _ := slices.Map(taskGroups,
-		func(i int, taskGroup twapi.TasksGroup) (string, error) {
-			idStr := strconv.Itoa(int(taskGroup.Project.ID))
-			return idStr, nil
+		func(i int, taskGroup twapi.TasksGroup) (twapi.ID, error) {
+			return taskGroup.Project.ID, nil
 		},
 	)
 
@@ -55,27 +88,15 @@ func getProjectsAndTasks(
 		},
 	)
 
-	taskGroupsWithoutTasks, err := slices.Map(projectsWithoutTasks,
+	taskGroupsWithoutTasks, _ := slices.Map(projectsWithoutTasks,
 		func(i int, project twapi.Project) (twapi.TasksGroup, error) {
-			id, err := strconv.Atoi(project.ID)
-			if err != nil {
-				return twapi.TasksGroup{}, err
-			}
-
 			return twapi.TasksGroup{
-				Project: twapi.TaskProject{
-					ID:   uint64(id),
-					Name: project.Name,
-				},
-				Tasks: []twapi.Task{},
+				Project: project,
+				Tasks:   []twapi.Task{},
 			}, nil
 		},
 	)
 
-	if err != nil {
-		return nil, err
-	}
-
 	taskGroups = append(taskGroups, taskGroupsWithoutTasks...)
 
 	return taskGroups, nil
@@ -105,7 +126,7 @@ func selectTimelogTarget(taskGroups []twapi.TasksGroup) (*timelogTargetSelection
 				return selection.Project.Name, nil
 			}
 
-			return fmt.Sprintf("%s / %s", selection.Project.Name, selection.Task.Content), nil
+			return fmt.Sprintf("%s / %s", selection.Project.Name, describeTask(selection.Task)), nil
 		},
 	)
 
@@ -146,18 +167,72 @@ func selectAccount(accounts twapi.AccountsResponse) (twapi.Account, error) {
 	return accounts.Accounts[accountIndex], nil
 }
 
+// findAccountByAPIEndPoint returns the account of the installation
+// with the given API endpoint
+func findAccountByAPIEndPoint(accounts twapi.AccountsResponse, apiEndPoint string) (twapi.Account, error) {
+	for _, account := range accounts.Accounts {
+		if account.Installation.ApiEndPoint == apiEndPoint {
+			return account, nil
+		}
+	}
+
+	return twapi.Account{}, fmt.Errorf("no account found for %s", apiEndPoint)
+}
+
+// findAccount returns the account of the installation
+// with the given ID or name (case insensitive)
+func findAccount(accounts twapi.AccountsResponse, idOrName string) (twapi.Account, error) {
+	for _, account := range accounts.Accounts {
+		if account.Installation.ID.String() == idOrName ||
+			strings.EqualFold(account.Installation.Name, idOrName) {
+			return account, nil
+		}
+	}
+
+	return twapi.Account{}, fmt.Errorf("no account found for installation %q", idOrName)
+}
+
+// scanln reads a word from the standard input, like fmt.Scanln.
+// Unlike fmt.Scanln, it stops waiting once the context is done, e.g. on Ctrl-C,
+// and returns the error of the context. The input is still read in the background then,
+// which is fine as the command is about to exit.
+func scanln(ctx context.Context) (string, error) {
+	type input struct {
+		word string
+		err  error
+	}
+
+	inputs := make(chan input, 1)
+	go func() {
+		var word string
+		_, err := fmt.Scanln(&word)
+		inputs <- input{word, err}
+	}()
+
+	select {
+	case <-ctx.Done():
+		// the prompt is left without the new line
+		fmt.Println()
+		return "", ctx.Err()
+	case in := <-inputs:
+		return in.word, in.err
+	}
+}
+
 // askStartTime returns a time.Time in the format of HH:mm
 // by default (if a user just hits Return) it returns 09:00
 // Loops until a valid input is given
-func askStartTime() time.Time {
+func askStartTime(ctx context.Context) (time.Time, error) {
 	//TODO: make this configurable
 	defaultStartTime := time.Date(0, 0, 0, 9, 0, 0, 0, time.UTC)
 
 	for {
-		var startTimeStr string
 		fmt.Print("Start time (HH:mm): ")
 		color.Yellow(" (default: %s)", defaultStartTime.Format("15:04"))
-		_, err := fmt.Scanln(&startTimeStr)
+		startTimeStr, err := scanln(ctx)
+		if ctx.Err() != nil {
+			return time.Time{}, ctx.Err()
+		}
 		//unexpected newline is returned when user hits Return, so we ignore it
 		if err != nil && err.Error() != "unexpected newline" {
 			fmt.Println("Invalid input")
@@ -165,7 +240,7 @@ func askStartTime() time.Time {
 		}
 
 		if startTimeStr == "" {
-			return time.Date(0, 0, 0, 9, 0, 0, 0, time.UTC)
+			return time.Date(0, 0, 0, 9, 0, 0, 0, time.UTC), nil
 		}
 
 		startTime, err := time.Parse("15:04", startTimeStr)
@@ -174,28 +249,30 @@ func askStartTime() time.Time {
 			continue
 		}
 
-		return startTime
+		return startTime, nil
 	}
 }
 
 // askDate returns a time.Time in the format of YYYY-MM-DD
 // by default (if a user just hits Return) it returns today's date
 // Loops until a valid input is given
-func askDate() time.Time {
+func askDate(ctx context.Context) (time.Time, error) {
 	defaultDate := time.Now()
 
 	for {
-		var dateStr string
 		fmt.Print("Date (YYYY-MM-DD): ")
 		color.Yellow(" (default: %s)", defaultDate.Format("2006-01-02"))
-		_, err := fmt.Scanln(&dateStr)
+		dateStr, err := scanln(ctx)
+		if ctx.Err() != nil {
+			return time.Time{}, ctx.Err()
+		}
 		if err != nil && err.Error() != "unexpected newline" {
 			fmt.Println("Invalid input")
 			continue
 		}
 
 		if dateStr == "" {
-			return defaultDate
+			return defaultDate, nil
 		}
 
 		date, err := time.Parse("2006-01-02", dateStr)
@@ -204,18 +281,20 @@ func askDate() time.Time {
 			continue
 		}
 
-		return date
+		return date, nil
 	}
 }
 
 //returns a time.Duration between 0 and 24 hours
 //expects a string in the format of "8.5" for 8 hours and 30 minutes
 //Loops until a valid input is given
-func askDuration() time.Duration {
+func askDuration(ctx context.Context) (time.Duration, error) {
 	for {
-		var durationStr string
 		fmt.Print("Duration (in hours): ")
-		_, err := fmt.Scanln(&durationStr)
+		durationStr, err := scanln(ctx)
+		if ctx.Err() != nil {
+			return 0, ctx.Err()
+		}
 		if err != nil {
 			fmt.Println("Invalid input")
 			continue
@@ -232,14 +311,80 @@ func askDuration() time.Duration {
 			continue
 		}
 
-		return time.Duration(duration * float64(time.Hour))
+		return time.Duration(duration * float64(time.Hour)), nil
+	}
+}
+
+// getEmail returns the email from the options, TEAMJERK_EMAIL
+// or asks for it interactively
+func getEmail(ctx context.Context, options LoginOptions) (string, error) {
+	if options.Email != "" {
+		return options.Email, nil
+	}
+
+	if email := os.Getenv("TEAMJERK_EMAIL"); email != "" {
+		return email, nil
+	}
+
+	return askEmail(ctx)
+}
+
+// getPassword returns the password from the first found source:
+// the standard input (if requested), TEAMJERK_PASSWORD, the password command.
+// Otherwise asks for it interactively.
+func getPassword(ctx context.Context, options LoginOptions) (string, error) {
+	if options.PasswordStdin {
+		return readPasswordFromStdin()
+	}
+
+	if password := os.Getenv("TEAMJERK_PASSWORD"); password != "" {
+		return password, nil
+	}
+
+	if options.PasswordCommand != "" {
+		return runPasswordCommand(ctx, options.PasswordCommand)
+	}
+
+	return askPassword()
+}
+
+// readPasswordFromStdin reads the first line of the standard input
+func readPasswordFromStdin() (string, error) {
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", fmt.Errorf("failed to read the password from stdin: %w", err)
+	}
+
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// runPasswordCommand runs the command in the shell
+// and returns the first line of its output, e.g. for "pass show teamwork"
+func runPasswordCommand(ctx context.Context, command string) (string, error) {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+
+	// the command may need to ask for a master password (e.g. gpg)
+	cmd.Stdin = os.Stdin
+	cmd.Stderr = os.Stderr
+
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("password command failed: %w", err)
 	}
+
+	line, _, _ := bytes.Cut(output, []byte("\n"))
+
+	return strings.TrimRight(string(line), "\r"), nil
 }
 
-func askEmail() (string, error) {
-	var email string
+func askEmail(ctx context.Context) (string, error) {
 	fmt.Print("Email: ")
-	_, err := fmt.Scanln(&email)
+	email, err := scanln(ctx)
 	if err != nil {
 		return "", err
 	}
@@ -256,3 +401,183 @@ func askPassword() (string, error) {
 
 	return string(password), nil
 }
+
+// askOTP asks for the two-factor authentication code
+// generated by the authenticator app
+func askOTP(ctx context.Context) (string, error) {
+	fmt.Print("Authentication code: ")
+	code, err := scanln(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(code), nil
+}
+
+func askAPIKey() (string, error) {
+	apiKey, err := gopass.GetPasswdPrompt("API key: ",
+		false, os.Stdin, os.Stdout)
+	if err != nil {
+		return "", err
+	}
+
+	return string(apiKey), nil
+}
+
+func askCookie() (string, error) {
+	cookie, err := gopass.GetPasswdPrompt("Value of the 'tw-auth' cookie: ",
+		false, os.Stdin, os.Stdout)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(cookie)), nil
+}
+
+func askInstallationURL(ctx context.Context) (string, error) {
+	fmt.Print("Installation URL (e.g. https://example.teamwork.com): ")
+	installationURL, err := scanln(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	return installationURL, nil
+}
+
+// normalizeInstallationURL turns a user provided installation URL
+// into an API endpoint, e.g. "example.teamwork.com" -> "https://example.teamwork.com/"
+func normalizeInstallationURL(installationURL string) (string, error) {
+	installationURL = strings.TrimSpace(installationURL)
+	if !strings.Contains(installationURL, "://") {
+		installationURL = "https://" + installationURL
+	}
+
+	u, err := url.Parse(installationURL)
+	if err != nil {
+		return "", err
+	}
+	if u.Host == "" {
+		return "", fmt.Errorf("invalid installation URL: %q", installationURL)
+	}
+
+	return u.Scheme + "://" + u.Host + "/", nil
+}
+
+// isInteractive returns true if the standard input is a terminal
+func isInteractive() bool {
+	return term.IsTerminal(int(os.Stdin.Fd()))
+}
+
+// askWithDefault asks for a value, pre-filled with the default one which can be edited.
+// validate may be nil.
+func askWithDefault(label, defaultValue string, validate func(string) error) (string, error) {
+	prompt := promptui.Prompt{
+		Label:     label,
+		Default:   defaultValue,
+		AllowEdit: true,
+		Validate:  validate,
+	}
+
+	return prompt.Run()
+}
+
+// askConfirmation asks a yes/no question.
+// by default (if a user just hits Return) it returns true
+func askConfirmation(ctx context.Context, label string) (bool, error) {
+	for {
+		fmt.Printf("%s [Y/n]: ", label)
+		answer, err := scanln(ctx)
+		if ctx.Err() != nil {
+			return false, ctx.Err()
+		}
+		if err != nil && err.Error() != "unexpected newline" {
+			return false, nil
+		}
+
+		switch strings.ToLower(answer) {
+		case "", "y", "yes":
+			return true, nil
+		case "n", "no":
+			return false, nil
+		}
+	}
+}
+
+// askDangerousConfirmation asks a yes/no question about an action which can't be undone.
+// Unlike askConfirmation, it returns false by default (if a user just hits Return)
+func askDangerousConfirmation(ctx context.Context, label string) (bool, error) {
+	for {
+		fmt.Printf("%s [y/N]: ", label)
+		answer, err := scanln(ctx)
+		if ctx.Err() != nil {
+			return false, ctx.Err()
+		}
+		if err != nil && err.Error() != "unexpected newline" {
+			return false, nil
+		}
+
+		switch strings.ToLower(answer) {
+		case "y", "yes":
+			return true, nil
+		case "", "n", "no":
+			return false, nil
+		}
+	}
+}
+
+// regionFromAPIEndPoint guesses the region of the installation
+// from its URL, e.g. "https://example.eu.teamwork.com/" -> "EU"
+func regionFromAPIEndPoint(apiEndPoint string) string {
+	u, err := url.Parse(apiEndPoint)
+	if err != nil {
+		return ""
+	}
+
+	if strings.HasSuffix(u.Hostname(), ".eu.teamwork.com") {
+		return "EU"
+	}
+	if strings.HasSuffix(u.Hostname(), ".teamwork.com") {
+		return "US"
+	}
+
+	return ""
+}
+
+// plannedDay is a day of the range to log
+type plannedDay struct {
+	Date time.Time
+	// Skip is the reason the day is skipped: "weekend" or "holiday",
+	// empty for the working days
+	Skip string
+}
+
+// Format returns the date with the day of the week, e.g. "2023-03-04 Sat"
+func (d plannedDay) Format() string {
+	return d.Date.Format("2006-01-02 Mon")
+}
+
+// planDays returns every day between from and to, inclusive,
+// with the weekends and the holidays marked as skipped
+func planDays(from, to time.Time, holidays []time.Time) []plannedDay {
+	isHoliday := map[string]bool{}
+	for _, holiday := range holidays {
+		isHoliday[holiday.Format("2006-01-02")] = true
+	}
+
+	days := []plannedDay{}
+
+	for date := from; !date.After(to); date = date.AddDate(0, 0, 1) {
+		day := plannedDay{Date: date}
+
+		switch {
+		case date.Weekday() == time.Saturday || date.Weekday() == time.Sunday:
+			day.Skip = "weekend"
+		case isHoliday[date.Format("2006-01-02")]:
+			day.Skip = "holiday"
+		}
+
+		days = append(days, day)
+	}
+
+	return days
+}
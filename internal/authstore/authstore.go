@@ -1,64 +1,54 @@
 package authstore
 
 import (
-	"encoding/json"
-	"io/ioutil"
-	"os"
-	"path/filepath"
+	"github.com/harnyk/teamjerk/internal/jsonfile"
 )
 
 type AuthStore[T any] interface {
 	Load() (*T, error)
 	Save(authData *T) error
 	Exists() bool
+	Remove() error
 }
 
 type authStore[T any] struct {
-	jsonFile string
+	file *jsonfile.File[T]
 }
 
-func NewAuthStore[T any](jsonFile string) AuthStore[T] {
-	return &authStore[T]{jsonFile: jsonFile}
+// NewAuthStore returns an AuthStore keeping the data as plain JSON.
+// The migrations upgrade the documents written by older versions.
+func NewAuthStore[T any](jsonFile string, migrations ...jsonfile.Migration) AuthStore[T] {
+	return &authStore[T]{file: jsonfile.New[T](jsonFile, migrations...)}
 }
 
 func (s *authStore[T]) Load() (*T, error) {
-	fileContent, err := ioutil.ReadFile(s.jsonFile)
-	if err != nil {
-		return nil, err
-	}
+	return s.file.Load()
+}
 
-	authData := new(T)
-	err = json.Unmarshal(fileContent, authData)
-	if err != nil {
-		return nil, err
-	}
+func (s *authStore[T]) Save(authData *T) error {
+	return s.file.Save(authData)
+}
 
-	return authData, nil
+func (s *authStore[T]) Exists() bool {
+	return s.file.Exists()
 }
 
-func (s *authStore[T]) Save(authData *T) error {
-	jsonContent, err := json.Marshal(authData)
-	if err != nil {
-		return err
-	}
+func (s *authStore[T]) Remove() error {
+	return s.file.Remove()
+}
 
-	err = os.MkdirAll(filepath.Dir(s.jsonFile), 0700)
+// Migrate copies the data from one store to another
+// and removes it from the source store.
+func Migrate[T any](from, to AuthStore[T]) error {
+	authData, err := from.Load()
 	if err != nil {
 		return err
 	}
 
-	err = ioutil.WriteFile(s.jsonFile, jsonContent, 0600)
+	err = to.Save(authData)
 	if err != nil {
 		return err
 	}
 
-	return nil
-}
-
-func (s *authStore[T]) Exists() bool {
-	if _, err := os.Stat(s.jsonFile); os.IsNotExist(err) {
-		return false
-	}
-
-	return true
+	return from.Remove()
 }
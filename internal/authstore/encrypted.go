@@ -0,0 +1,249 @@
+package authstore
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/harnyk/teamjerk/internal/jsonfile"
+	"golang.org/x/crypto/scrypt"
+)
+
+// PassphraseFunc returns the passphrase used to derive the encryption key.
+// confirm is true when a new store is being created,
+// so the implementation may ask for the passphrase twice.
+type PassphraseFunc func(confirm bool) (string, error)
+
+var ErrInvalidPassphrase = errors.New("invalid passphrase")
+
+const (
+	// encryptedFileVersion is the schema version of the encrypted file envelope
+	encryptedFileVersion = 1
+
+	kdfScrypt = "scrypt"
+
+	scryptN      = 1 << 15
+	scryptR      = 8
+	scryptP      = 1
+	scryptKeyLen = 32
+	saltLen      = 16
+)
+
+// encryptedFile is the on-disk format of the encrypted store.
+// []byte fields are base64-encoded by encoding/json.
+type encryptedFile struct {
+	KDF        string `json:"kdf"`
+	N          int    `json:"n"`
+	R          int    `json:"r"`
+	P          int    `json:"p"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+type encryptedAuthStore[T any] struct {
+	file       string
+	passphrase PassphraseFunc
+	// migrations upgrade the decrypted documents written by older versions
+	migrations []jsonfile.Migration
+
+	// the key is cached after the first successful derivation,
+	// so the passphrase is asked only once per process
+	salt []byte
+	key  []byte
+}
+
+// NewEncryptedAuthStore returns an AuthStore which keeps the data
+// encrypted with AES-256-GCM, using a key derived from a passphrase with scrypt.
+// The migrations upgrade the documents written by older versions.
+func NewEncryptedAuthStore[T any](file string, passphrase PassphraseFunc, migrations ...jsonfile.Migration) AuthStore[T] {
+	return &encryptedAuthStore[T]{file: file, passphrase: passphrase, migrations: migrations}
+}
+
+func (s *encryptedAuthStore[T]) Load() (*T, error) {
+	ef, err := s.readFile()
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := s.decrypt(ef)
+	if err != nil {
+		return nil, err
+	}
+
+	authData := new(T)
+	err = jsonfile.Unmarshal(plaintext, authData, s.migrations)
+	if err != nil {
+		return nil, err
+	}
+
+	return authData, nil
+}
+
+func (s *encryptedAuthStore[T]) Save(authData *T) error {
+	// the lock also covers reading the salt of the existing file
+	unlock, err := jsonfile.Lock(s.file)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if s.key == nil {
+		if err := s.initKey(); err != nil {
+			return err
+		}
+	}
+
+	plaintext, err := jsonfile.Marshal(authData, len(s.migrations)+1)
+	if err != nil {
+		return err
+	}
+
+	gcm, err := newGCM(s.key)
+	if err != nil {
+		return err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return err
+	}
+
+	ef := &encryptedFile{
+		KDF:        kdfScrypt,
+		N:          scryptN,
+		R:          scryptR,
+		P:          scryptP,
+		Salt:       s.salt,
+		Nonce:      nonce,
+		Ciphertext: gcm.Seal(nil, nonce, plaintext, nil),
+	}
+
+	jsonContent, err := jsonfile.Marshal(ef, encryptedFileVersion)
+	if err != nil {
+		return err
+	}
+
+	return jsonfile.WriteAtomic(s.file, jsonContent, 0600)
+}
+
+func (s *encryptedAuthStore[T]) Exists() bool {
+	if _, err := os.Stat(s.file); os.IsNotExist(err) {
+		return false
+	}
+
+	return true
+}
+
+func (s *encryptedAuthStore[T]) Remove() error {
+	unlock, err := jsonfile.Lock(s.file)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	err = os.Remove(s.file)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
+
+// initKey prepares the key for Save.
+// If the store already exists, the passphrase is verified against it,
+// otherwise a new salt is generated.
+func (s *encryptedAuthStore[T]) initKey() error {
+	if s.Exists() {
+		ef, err := s.readFile()
+		if err != nil {
+			return err
+		}
+
+		_, err = s.decrypt(ef)
+		return err
+	}
+
+	passphrase, err := s.passphrase(true)
+	if err != nil {
+		return err
+	}
+
+	salt := make([]byte, saltLen)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return err
+	}
+
+	key, err := scrypt.Key([]byte(passphrase), salt, scryptN, scryptR, scryptP, scryptKeyLen)
+	if err != nil {
+		return err
+	}
+
+	s.salt = salt
+	s.key = key
+
+	return nil
+}
+
+func (s *encryptedAuthStore[T]) readFile() (*encryptedFile, error) {
+	fileContent, err := ioutil.ReadFile(s.file)
+	if err != nil {
+		return nil, err
+	}
+
+	ef := &encryptedFile{}
+	err = jsonfile.Unmarshal(fileContent, ef, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", s.file, err)
+	}
+
+	if ef.KDF != kdfScrypt {
+		return nil, fmt.Errorf("unsupported key derivation function: %q", ef.KDF)
+	}
+
+	return ef, nil
+}
+
+func (s *encryptedAuthStore[T]) decrypt(ef *encryptedFile) ([]byte, error) {
+	key := s.key
+	if key == nil || string(s.salt) != string(ef.Salt) {
+		passphrase, err := s.passphrase(false)
+		if err != nil {
+			return nil, err
+		}
+
+		key, err = scrypt.Key([]byte(passphrase), ef.Salt, ef.N, ef.R, ef.P, scryptKeyLen)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := gcm.Open(nil, ef.Nonce, ef.Ciphertext, nil)
+	if err != nil {
+		return nil, ErrInvalidPassphrase
+	}
+
+	s.salt = ef.Salt
+	s.key = key
+
+	return plaintext, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
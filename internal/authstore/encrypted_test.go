@@ -0,0 +1,65 @@
+package authstore_test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/harnyk/teamjerk/internal/authstore"
+	"github.com/ysmood/got"
+)
+
+type testAuthData struct {
+	APIEndPoint string
+	Token       string
+}
+
+func fixedPassphrase(passphrase string) authstore.PassphraseFunc {
+	return func(confirm bool) (string, error) {
+		return passphrase, nil
+	}
+}
+
+func TestEncryptedAuthStore_RoundTrip(t *testing.T) {
+	g := got.T(t)
+	file := filepath.Join(t.TempDir(), "auth.enc.json")
+
+	store := authstore.NewEncryptedAuthStore[testAuthData](file, fixedPassphrase("secret"))
+	g.Eq(store.Exists(), false)
+
+	err := store.Save(&testAuthData{APIEndPoint: "https://example.teamwork.com/", Token: "tw-auth-token"})
+	g.Eq(err, nil)
+	g.Eq(store.Exists(), true)
+
+	fileContent, err := ioutil.ReadFile(file)
+	g.Eq(err, nil)
+	g.Eq(strings.Contains(string(fileContent), "tw-auth-token"), false)
+
+	loaded, err := authstore.NewEncryptedAuthStore[testAuthData](file, fixedPassphrase("secret")).Load()
+	g.Eq(err, nil)
+	g.Eq(loaded, &testAuthData{APIEndPoint: "https://example.teamwork.com/", Token: "tw-auth-token"})
+
+	_, err = authstore.NewEncryptedAuthStore[testAuthData](file, fixedPassphrase("wrong")).Load()
+	g.Eq(err, authstore.ErrInvalidPassphrase)
+
+	g.Eq(store.Remove(), nil)
+	g.Eq(store.Exists(), false)
+}
+
+func TestMigrate(t *testing.T) {
+	g := got.T(t)
+	dir := t.TempDir()
+
+	plain := authstore.NewAuthStore[testAuthData](filepath.Join(dir, "auth.json"))
+	encrypted := authstore.NewEncryptedAuthStore[testAuthData](filepath.Join(dir, "auth.enc.json"), fixedPassphrase("secret"))
+
+	g.Eq(plain.Save(&testAuthData{Token: "tw-auth-token"}), nil)
+	g.Eq(authstore.Migrate(plain, encrypted), nil)
+
+	g.Eq(plain.Exists(), false)
+
+	loaded, err := encrypted.Load()
+	g.Eq(err, nil)
+	g.Eq(loaded.Token, "tw-auth-token")
+}
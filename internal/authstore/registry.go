@@ -0,0 +1,104 @@
+package authstore
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Registry keeps several named stores side by side in one directory
+type Registry[T any] interface {
+	Names() ([]string, error)
+	Get(name string) (AuthStore[T], error)
+}
+
+type registry[T any] struct {
+	dir      string
+	ext      string
+	newStore func(file string) AuthStore[T]
+}
+
+var validName = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// NewRegistry returns a Registry which keeps every named store
+// in the file <dir>/<name><ext> created by newStore.
+func NewRegistry[T any](dir, ext string, newStore func(file string) AuthStore[T]) Registry[T] {
+	return &registry[T]{dir: dir, ext: ext, newStore: newStore}
+}
+
+// ValidateName checks that the name can be used as a store name
+func ValidateName(name string) error {
+	if !validName.MatchString(name) {
+		return fmt.Errorf("invalid name %q: only letters, digits, '-' and '_' are allowed", name)
+	}
+
+	return nil
+}
+
+func (r *registry[T]) Names() ([]string, error) {
+	entries, err := ioutil.ReadDir(r.dir)
+	if os.IsNotExist(err) {
+		return []string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	names := []string{}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), r.ext) {
+			continue
+		}
+
+		name := strings.TrimSuffix(entry.Name(), r.ext)
+		if ValidateName(name) != nil {
+			continue
+		}
+
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names, nil
+}
+
+func (r *registry[T]) Get(name string) (AuthStore[T], error) {
+	if err := ValidateName(name); err != nil {
+		return nil, err
+	}
+
+	return r.newStore(filepath.Join(r.dir, name+r.ext)), nil
+}
+
+// MoveIntoRegistry moves the store file kept outside of a registry
+// to the store <dir>/<name><ext>, unless that store already exists.
+// A missing file is not an error.
+func MoveIntoRegistry(file, dir, name, ext string) error {
+	if err := ValidateName(name); err != nil {
+		return err
+	}
+
+	storeFile := filepath.Join(dir, name+ext)
+
+	if _, err := os.Stat(file); os.IsNotExist(err) {
+		return nil
+	}
+	if _, err := os.Stat(storeFile); err == nil {
+		return nil
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	// another process may have moved it in the meantime
+	if err := os.Rename(file, storeFile); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,138 @@
+package authstore_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/harnyk/teamjerk/internal/authstore"
+	"github.com/ysmood/got"
+)
+
+func newPlainRegistry(dir string) authstore.Registry[testAuthData] {
+	return authstore.NewRegistry(dir, ".json", func(file string) authstore.AuthStore[testAuthData] {
+		return authstore.NewAuthStore[testAuthData](file)
+	})
+}
+
+func newEncryptedRegistry(dir string) authstore.Registry[testAuthData] {
+	return authstore.NewRegistry(dir, ".enc.json", func(file string) authstore.AuthStore[testAuthData] {
+		return authstore.NewEncryptedAuthStore[testAuthData](file, fixedPassphrase("secret"))
+	})
+}
+
+func TestRegistry_PlainProfiles(t *testing.T) {
+	g := got.T(t)
+	dir := filepath.Join(t.TempDir(), "profiles")
+	registry := newPlainRegistry(dir)
+
+	names, err := registry.Names()
+	g.E(err)
+	g.Eq(names, []string{})
+
+	for _, name := range []string{"work", "default"} {
+		store, err := registry.Get(name)
+		g.E(err)
+		g.E(store.Save(&testAuthData{Token: name + "-token"}))
+	}
+
+	names, err = registry.Names()
+	g.E(err)
+	g.Eq(names, []string{"default", "work"})
+
+	g.Eq(fileExists(filepath.Join(dir, "work.json")), true)
+
+	store, err := registry.Get("work")
+	g.E(err)
+	loaded, err := store.Load()
+	g.E(err)
+	g.Eq(loaded.Token, "work-token")
+}
+
+func TestRegistry_EncryptedProfiles(t *testing.T) {
+	g := got.T(t)
+	dir := t.TempDir()
+
+	store, err := newEncryptedRegistry(dir).Get("work")
+	g.E(err)
+	g.E(store.Save(&testAuthData{Token: "work-token"}))
+	g.Eq(fileExists(filepath.Join(dir, "work.enc.json")), true)
+
+	names, err := newEncryptedRegistry(dir).Names()
+	g.E(err)
+	g.Eq(names, []string{"work"})
+
+	// the encrypted files are not taken for the plain profiles
+	names, err = newPlainRegistry(dir).Names()
+	g.E(err)
+	g.Eq(names, []string{})
+
+	store, err = newEncryptedRegistry(dir).Get("work")
+	g.E(err)
+	loaded, err := store.Load()
+	g.E(err)
+	g.Eq(loaded.Token, "work-token")
+}
+
+func TestRegistry_InvalidNames(t *testing.T) {
+	g := got.T(t)
+	dir := t.TempDir()
+	registry := newPlainRegistry(dir)
+
+	for _, name := range []string{"", "work.old", "../work", "my profile", "work/home"} {
+		g.Err(authstore.ValidateName(name))
+
+		_, err := registry.Get(name)
+		g.Err(err)
+	}
+
+	for _, name := range []string{"work", "Work_2", "client-a"} {
+		g.E(authstore.ValidateName(name))
+	}
+
+	// the files with invalid names are not listed
+	g.E(ioutil.WriteFile(filepath.Join(dir, "work.old.json"), []byte("{}"), 0600))
+	g.E(os.Mkdir(filepath.Join(dir, "backup.json"), 0700))
+
+	names, err := registry.Names()
+	g.E(err)
+	g.Eq(names, []string{})
+}
+
+func TestMoveIntoRegistry(t *testing.T) {
+	g := got.T(t)
+	stateDir := t.TempDir()
+	legacyFile := filepath.Join(stateDir, "auth.json")
+	profilesDir := filepath.Join(stateDir, "profiles")
+
+	// nothing to move
+	g.E(authstore.MoveIntoRegistry(legacyFile, profilesDir, "default", ".json"))
+	g.Eq(fileExists(profilesDir), false)
+
+	g.E(authstore.NewAuthStore[testAuthData](legacyFile).Save(&testAuthData{Token: "legacy-token"}))
+
+	g.E(authstore.MoveIntoRegistry(legacyFile, profilesDir, "default", ".json"))
+	g.Eq(fileExists(legacyFile), false)
+
+	store, err := newPlainRegistry(profilesDir).Get("default")
+	g.E(err)
+	loaded, err := store.Load()
+	g.E(err)
+	g.Eq(loaded.Token, "legacy-token")
+
+	// an existing profile is not overwritten
+	g.E(authstore.NewAuthStore[testAuthData](legacyFile).Save(&testAuthData{Token: "other-token"}))
+
+	g.E(authstore.MoveIntoRegistry(legacyFile, profilesDir, "default", ".json"))
+	g.Eq(fileExists(legacyFile), true)
+
+	loaded, err = store.Load()
+	g.E(err)
+	g.Eq(loaded.Token, "legacy-token")
+}
+
+func fileExists(file string) bool {
+	_, err := os.Stat(file)
+	return err == nil
+}
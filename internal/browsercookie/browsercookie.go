@@ -0,0 +1,182 @@
+// Package browsercookie reads cookies from the cookie databases
+// of Firefox (cookies.sqlite) and Chromium-based browsers (Cookies).
+//
+// The databases are queried with the sqlite3 command line tool,
+// which has to be installed.
+package browsercookie
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var ErrNotFound = errors.New("cookie not found")
+
+var validHost = regexp.MustCompile(`^[A-Za-z0-9.-]+$`)
+
+// Read returns the value of the cookie with the given name set for the host
+// or for one of its parent domains, the most specific one wins.
+// The browser is detected by the database schema.
+func Read(dbFile, host, name string) (string, error) {
+	if !validHost.MatchString(host) {
+		return "", fmt.Errorf("invalid host: %q", host)
+	}
+	if !validHost.MatchString(name) {
+		return "", fmt.Errorf("invalid cookie name: %q", name)
+	}
+
+	// the browser keeps the database locked while running,
+	// so a copy is queried instead
+	tmpDir, err := os.MkdirTemp("", "teamjerk-cookies")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	dbCopy := filepath.Join(tmpDir, "cookies.db")
+	if err := copyDatabase(dbFile, dbCopy); err != nil {
+		return "", err
+	}
+
+	tables, err := query(dbCopy, "SELECT name FROM sqlite_master WHERE type='table';")
+	if err != nil {
+		return "", err
+	}
+
+	hosts := "('" + strings.Join(cookieDomains(host), "', '") + "')"
+
+	switch {
+	case contains(tables, "moz_cookies"):
+		rows, err := query(dbCopy, fmt.Sprintf(
+			"SELECT value FROM moz_cookies WHERE name = '%s' AND host IN %s ORDER BY length(host) DESC, expiry DESC LIMIT 1;",
+			name, hosts,
+		))
+		if err != nil {
+			return "", err
+		}
+		if len(rows) == 0 {
+			return "", ErrNotFound
+		}
+
+		return rows[0], nil
+
+	case contains(tables, "cookies") && contains(tables, "meta"):
+		rows, err := query(dbCopy, fmt.Sprintf(
+			"SELECT value || '|' || hex(encrypted_value) FROM cookies WHERE name = '%s' AND host_key IN %s ORDER BY length(host_key) DESC, expires_utc DESC LIMIT 1;",
+			name, hosts,
+		))
+		if err != nil {
+			return "", err
+		}
+		if len(rows) == 0 {
+			return "", ErrNotFound
+		}
+
+		value, encryptedHex, _ := strings.Cut(rows[0], "|")
+		if value != "" {
+			return value, nil
+		}
+
+		versions, err := query(dbCopy, "SELECT value FROM meta WHERE key = 'version';")
+		if err != nil {
+			return "", err
+		}
+
+		return decryptChromiumValue(encryptedHex, versions)
+
+	default:
+		return "", fmt.Errorf("%s is not a Firefox or Chromium cookie database", dbFile)
+	}
+}
+
+// cookieDomains returns the cookie domains which match the host:
+// the host itself and, with a leading dot, the host and its parent domains
+// except for the top-level one, e.g. for example.teamwork.com
+// these are example.teamwork.com, .example.teamwork.com and .teamwork.com
+func cookieDomains(host string) []string {
+	domains := []string{host}
+
+	labels := strings.Split(host, ".")
+	for i := 0; i < len(labels)-1; i++ {
+		domains = append(domains, "."+strings.Join(labels[i:], "."))
+	}
+
+	return domains
+}
+
+// copyDatabase copies the database together with its write-ahead log,
+// which may contain the most recent cookies
+func copyDatabase(src, dst string) error {
+	if err := copyFile(src, dst); err != nil {
+		return err
+	}
+
+	err := copyFile(src+"-wal", dst+"-wal")
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	_, err = io.Copy(out, in)
+
+	return err
+}
+
+// query runs the SQL query with the sqlite3 tool
+// and returns the output lines
+func query(dbFile, sql string) ([]string, error) {
+	var stderr bytes.Buffer
+
+	cmd := exec.Command("sqlite3", "-batch", "-noheader", dbFile, sql)
+	cmd.Stderr = &stderr
+
+	output, err := cmd.Output()
+	if errors.Is(err, exec.ErrNotFound) {
+		return nil, fmt.Errorf("sqlite3 is required to read the cookie database: %w", err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("sqlite3: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	lines := []string{}
+	for _, line := range strings.Split(string(output), "\n") {
+		line = strings.TrimRight(line, "\r")
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	return lines, nil
+}
+
+func contains(items []string, item string) bool {
+	for _, i := range items {
+		if i == item {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,99 @@
+package browsercookie
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"encoding/hex"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/ysmood/got"
+)
+
+func encryptChromiumValue(host, value string) string {
+	hash := sha256.Sum256([]byte(host))
+	plaintext := append(hash[:], []byte(value)...)
+
+	padding := aes.BlockSize - len(plaintext)%aes.BlockSize
+	for i := 0; i < padding; i++ {
+		plaintext = append(plaintext, byte(padding))
+	}
+
+	block, _ := aes.NewCipher(chromiumKey())
+	ciphertext := make([]byte, len(plaintext))
+	cipher.NewCBCEncrypter(block, chromiumIV).CryptBlocks(ciphertext, plaintext)
+
+	return hex.EncodeToString(append([]byte("v10"), ciphertext...))
+}
+
+func createDatabase(t *testing.T, sql string) string {
+	if _, err := exec.LookPath("sqlite3"); err != nil {
+		t.Skip("sqlite3 is not installed")
+	}
+
+	dbFile := filepath.Join(t.TempDir(), "cookies.db")
+	err := exec.Command("sqlite3", dbFile, sql).Run()
+	got.T(t).Eq(err, nil)
+
+	return dbFile
+}
+
+func TestRead_Firefox(t *testing.T) {
+	dbFile := createDatabase(t, `
+		CREATE TABLE moz_cookies (name TEXT, value TEXT, host TEXT, expiry INTEGER);
+		INSERT INTO moz_cookies VALUES ('tw-auth', 'old-token', 'example.teamwork.com', 1);
+		INSERT INTO moz_cookies VALUES ('tw-auth', 'firefox-token', 'example.teamwork.com', 2);
+		INSERT INTO moz_cookies VALUES ('tw-auth', 'other-token', 'other.teamwork.com', 3);
+	`)
+
+	value, err := Read(dbFile, "example.teamwork.com", "tw-auth")
+	got.T(t).Eq(err, nil)
+	got.T(t).Eq(value, "firefox-token")
+
+	_, err = Read(dbFile, "missing.teamwork.com", "tw-auth")
+	got.T(t).Eq(err, ErrNotFound)
+}
+
+func TestRead_ParentDomain(t *testing.T) {
+	dbFile := createDatabase(t, `
+		CREATE TABLE moz_cookies (name TEXT, value TEXT, host TEXT, expiry INTEGER);
+		INSERT INTO moz_cookies VALUES ('tw-auth', 'parent-token', '.teamwork.com', 2);
+		INSERT INTO moz_cookies VALUES ('tw-auth', 'tld-token', '.com', 3);
+		INSERT INTO moz_cookies VALUES ('session', 'host-token', 'example.teamwork.com', 1);
+		INSERT INTO moz_cookies VALUES ('session', 'parent-token', '.teamwork.com', 2);
+	`)
+
+	value, err := Read(dbFile, "example.teamwork.com", "tw-auth")
+	got.T(t).Eq(err, nil)
+	got.T(t).Eq(value, "parent-token")
+
+	// the cookie of the host wins over the one of the parent domain
+	value, err = Read(dbFile, "example.teamwork.com", "session")
+	got.T(t).Eq(err, nil)
+	got.T(t).Eq(value, "host-token")
+
+	_, err = Read(dbFile, "example.teamwork.eu", "tw-auth")
+	got.T(t).Eq(err, ErrNotFound)
+}
+
+func TestCookieDomains(t *testing.T) {
+	got.T(t).Eq(cookieDomains("example.teamwork.com"), []string{"example.teamwork.com", ".example.teamwork.com", ".teamwork.com"})
+	got.T(t).Eq(cookieDomains("localhost"), []string{"localhost"})
+}
+
+func TestRead_Chromium(t *testing.T) {
+	encrypted := encryptChromiumValue(".example.teamwork.com", "chromium-token")
+
+	dbFile := createDatabase(t, `
+		CREATE TABLE meta (key TEXT, value TEXT);
+		INSERT INTO meta VALUES ('version', '24');
+		CREATE TABLE cookies (host_key TEXT, name TEXT, value TEXT, encrypted_value BLOB, expires_utc INTEGER);
+		INSERT INTO cookies VALUES ('.example.teamwork.com', 'tw-auth', '', X'`+encrypted+`', 1);
+	`)
+
+	value, err := Read(dbFile, "example.teamwork.com", "tw-auth")
+	got.T(t).Eq(err, nil)
+	got.T(t).Eq(value, "chromium-token")
+}
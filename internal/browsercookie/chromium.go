@@ -0,0 +1,83 @@
+package browsercookie
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+/*
+
+Chromium on Linux without a keyring encrypts cookie values with AES-128-CBC.
+The key is derived from the hardcoded password "peanuts",
+and the encrypted value is prefixed with "v10".
+
+When a keyring (GNOME Keyring, KWallet) or the macOS Keychain is used,
+the password is stored there, the value is prefixed with "v11"
+(or "v10" on macOS) and it can't be decrypted without the keyring.
+
+Since the database version 24, the decrypted value is prefixed
+with the SHA-256 hash of the cookie domain.
+*/
+
+const (
+	chromiumPassword   = "peanuts"
+	chromiumSalt       = "saltysalt"
+	chromiumIterations = 1
+	chromiumKeyLen     = 16
+
+	// the database version since which the values are prefixed with the domain hash
+	chromiumDomainHashVersion = 24
+	domainHashLen             = 32
+)
+
+var chromiumIV = bytes.Repeat([]byte(" "), aes.BlockSize)
+
+func chromiumKey() []byte {
+	return pbkdf2.Key([]byte(chromiumPassword), []byte(chromiumSalt), chromiumIterations, chromiumKeyLen, sha1.New)
+}
+
+func decryptChromiumValue(encryptedHex string, versions []string) (string, error) {
+	encrypted, err := hex.DecodeString(encryptedHex)
+	if err != nil {
+		return "", err
+	}
+
+	if !bytes.HasPrefix(encrypted, []byte("v10")) {
+		return "", fmt.Errorf("the cookie is encrypted with a key from the system keyring, paste its value with --cookie instead")
+	}
+
+	ciphertext := encrypted[3:]
+	if len(ciphertext) == 0 || len(ciphertext)%aes.BlockSize != 0 {
+		return "", fmt.Errorf("invalid encrypted cookie length")
+	}
+
+	block, err := aes.NewCipher(chromiumKey())
+	if err != nil {
+		return "", err
+	}
+
+	plaintext := make([]byte, len(ciphertext))
+	cipher.NewCBCDecrypter(block, chromiumIV).CryptBlocks(plaintext, ciphertext)
+
+	padding := int(plaintext[len(plaintext)-1])
+	if padding < 1 || padding > aes.BlockSize || padding > len(plaintext) {
+		return "", fmt.Errorf("the cookie can't be decrypted, paste its value with --cookie instead")
+	}
+	plaintext = plaintext[:len(plaintext)-padding]
+
+	if len(versions) > 0 {
+		version, err := strconv.Atoi(versions[0])
+		if err == nil && version >= chromiumDomainHashVersion && len(plaintext) >= domainHashLen {
+			plaintext = plaintext[domainHashLen:]
+		}
+	}
+
+	return string(plaintext), nil
+}
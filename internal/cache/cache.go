@@ -0,0 +1,90 @@
+// Package cache keeps the data rarely changing on the Teamwork side
+// (the user profile, the projects and the tasks) in JSON files under ~/.teamjerk/cache,
+// to avoid the round trips on every command.
+package cache
+
+import (
+	"encoding/json"
+	"os"
+	"time"
+
+	"github.com/harnyk/teamjerk/internal/jsonfile"
+)
+
+// Item is a value of type T cached in a file for the TTL
+type Item[T any] interface {
+	// Get returns the cached value if it is fresh,
+	// otherwise fetches the value and caches it
+	Get(fetch func() (*T, error)) (*T, error)
+	// Peek returns the cached value even if it has expired,
+	// fresh tells whether it is still within the TTL.
+	// Returns nil if there is no usable cached value.
+	Peek() (value *T, fresh bool)
+	// Set caches the value
+	Set(value *T) error
+}
+
+type entry[T any] struct {
+	FetchedAt time.Time `json:"fetched_at"`
+	Data      *T        `json:"data"`
+}
+
+type item[T any] struct {
+	file *jsonfile.File[entry[T]]
+	ttl  time.Duration
+	// refresh makes the cached value ignored, so it is fetched again
+	refresh bool
+}
+
+// NewItem returns the value cached in the file.
+// With refresh, the cached value is ignored and replaced with the fetched one.
+// The migrations upgrade the files cached by older versions, see jsonfile.New.
+func NewItem[T any](file string, ttl time.Duration, refresh bool, migrations ...jsonfile.Migration) Item[T] {
+	return &item[T]{file: jsonfile.New[entry[T]](file, migrations...), ttl: ttl, refresh: refresh}
+}
+
+// Invalidate is a migration dropping the cached value,
+// for when its format has changed and it can't be upgraded
+func Invalidate(fields map[string]json.RawMessage) error {
+	delete(fields, "data")
+	return nil
+}
+
+func (i *item[T]) Get(fetch func() (*T, error)) (*T, error) {
+	if value, fresh := i.Peek(); fresh {
+		return value, nil
+	}
+
+	value, err := fetch()
+	if err != nil {
+		return nil, err
+	}
+
+	// the cache is an optimization, failing to write it is not an error
+	_ = i.Set(value)
+
+	return value, nil
+}
+
+func (i *item[T]) Peek() (*T, bool) {
+	if i.refresh {
+		return nil, false
+	}
+
+	// a broken or outdated file is treated as missing, it is overwritten on the next Set
+	cached, err := i.file.Load()
+	if err != nil || cached.Data == nil {
+		return nil, false
+	}
+
+	return cached.Data, time.Since(cached.FetchedAt) < i.ttl
+}
+
+func (i *item[T]) Set(value *T) error {
+	return i.file.Save(&entry[T]{FetchedAt: time.Now(), Data: value})
+}
+
+// Clear removes all the cached data in the directory
+func Clear(dir string) error {
+	return os.RemoveAll(dir)
+}
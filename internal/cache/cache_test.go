@@ -0,0 +1,81 @@
+package cache_test
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/harnyk/teamjerk/internal/cache"
+	"github.com/ysmood/got"
+)
+
+type value struct {
+	N int `json:"n"`
+}
+
+func TestItem(t *testing.T) {
+	g := got.T(t)
+	dir := t.TempDir()
+	file := filepath.Join(dir, "profile", "value.json")
+
+	fetched := 0
+	fetch := func() (*value, error) {
+		fetched++
+		return &value{N: fetched}, nil
+	}
+
+	item := cache.NewItem[value](file, time.Hour, false)
+
+	v, err := item.Get(fetch)
+	g.E(err)
+	g.Eq(v.N, 1)
+
+	v, err = item.Get(fetch)
+	g.E(err)
+	g.Eq(v.N, 1)
+
+	// another process sees the cached value
+	v, fresh := cache.NewItem[value](file, time.Hour, false).Peek()
+	g.Eq(v.N, 1)
+	g.True(fresh)
+
+	// expired values are still available for Peek, but Get fetches them again
+	expired := cache.NewItem[value](file, 0, false)
+	v, fresh = expired.Peek()
+	g.Eq(v.N, 1)
+	g.False(fresh)
+
+	v, err = expired.Get(fetch)
+	g.E(err)
+	g.Eq(v.N, 2)
+
+	// refresh ignores the cached value
+	refreshed := cache.NewItem[value](file, time.Hour, true)
+	v, _ = refreshed.Peek()
+	g.Nil(v)
+
+	v, err = refreshed.Get(fetch)
+	g.E(err)
+	g.Eq(v.N, 3)
+
+	// the fetch errors are returned, the cached value is kept
+	_, err = expired.Get(func() (*value, error) { return nil, errors.New("offline") })
+	g.Eq(err.Error(), "offline")
+	v, _ = item.Peek()
+	g.Eq(v.N, 3)
+
+	g.E(cache.Clear(dir))
+	v, _ = item.Peek()
+	g.Nil(v)
+}
+
+func TestInvalidate(t *testing.T) {
+	g := got.T(t)
+	file := filepath.Join(t.TempDir(), "value.json")
+
+	g.E(cache.NewItem[value](file, time.Hour, false).Set(&value{N: 1}))
+
+	v, _ := cache.NewItem[value](file, time.Hour, false, cache.Invalidate).Peek()
+	g.Nil(v)
+}
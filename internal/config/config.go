@@ -0,0 +1,67 @@
+package config
+
+import (
+	"os"
+
+	"github.com/harnyk/teamjerk/internal/jsonfile"
+)
+
+const (
+	AuthStorePlain     = "plain"
+	AuthStoreEncrypted = "encrypted"
+)
+
+// Config is the user configuration stored in ~/.teamjerk/config.json
+type Config struct {
+	// AuthStore selects the credential store backend,
+	// either AuthStorePlain (default) or AuthStoreEncrypted
+	AuthStore string `json:"auth_store,omitempty"`
+
+	// Profile is the name of the profile used when
+	// neither --profile nor TEAMJERK_PROFILE is given
+	Profile string `json:"profile,omitempty"`
+
+	// PasswordCommand is a shell command printing the password
+	// for "teamjerk login", e.g. "pass show teamwork"
+	PasswordCommand string `json:"password_command,omitempty"`
+
+	// CacheTTL is how long the profile, projects and tasks are cached,
+	// as a duration, e.g. "30m" or "24h"
+	CacheTTL string `json:"cache_ttl,omitempty"`
+
+	// LaunchpadURL replaces https://www.teamwork.com,
+	// which is used to find the accounts on login
+	LaunchpadURL string `json:"launchpad_url,omitempty"`
+
+	// APIEndPoint replaces the API endpoint of the installation
+	// for all the requests, e.g. https://example.teamwork.com/
+	APIEndPoint string `json:"api_endpoint,omitempty"`
+
+	// Proxy is the URL of the HTTP proxy, HTTPS_PROXY is used if empty
+	Proxy string `json:"proxy,omitempty"`
+
+	// CABundle is a PEM file with the certificate authorities
+	// trusted in addition to the system ones
+	CABundle string `json:"ca_bundle,omitempty"`
+
+	// Holidays are the days skipped when logging a range of dates,
+	// in the format YYYY-MM-DD, e.g. ["2023-12-25", "2023-12-26"]
+	Holidays []string `json:"holidays,omitempty"`
+}
+
+// Load reads the configuration from the given file.
+// If the file does not exist, an empty configuration is returned.
+func Load(file string) (*Config, error) {
+	cfg, err := jsonfile.New[Config](file).Load()
+	if os.IsNotExist(err) {
+		return &Config{}, nil
+	}
+
+	return cfg, err
+}
+
+// Update lets fn modify the configuration stored in the given file,
+// holding the lock so that concurrent updates are not lost
+func Update(file string, fn func(cfg *Config) error) error {
+	return jsonfile.New[Config](file).Update(fn)
+}
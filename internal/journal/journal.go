@@ -0,0 +1,92 @@
+// Package journal keeps the local record of the time entries submitted by teamjerk
+// in ~/.teamjerk/journal.json, so that they can be reviewed and undone.
+package journal
+
+import (
+	"os"
+	"time"
+
+	"github.com/harnyk/teamjerk/internal/jsonfile"
+	"github.com/harnyk/teamjerk/internal/twapi"
+)
+
+// The actions recorded in the journal
+const (
+	// ActionLog is the time entry logged with teamjerk log
+	ActionLog = "log"
+	// ActionUndo is the time entry deleted with teamjerk undo
+	ActionUndo = "undo"
+)
+
+type Record struct {
+	Time    time.Time `json:"time"`
+	Action  string    `json:"action"`
+	Profile string    `json:"profile"`
+	EntryID twapi.ID  `json:"entry_id"`
+	// Request is the submitted time entry, set for ActionLog
+	Request *twapi.LogtimeRequestWithProjectID `json:"request,omitempty"`
+}
+
+// Journal is the list of records shared by all the profiles
+type Journal interface {
+	// Append adds the record to the end of the journal
+	Append(record Record) error
+	// Records returns all the records, the oldest first
+	Records() ([]Record, error)
+}
+
+type document struct {
+	Records []Record `json:"records"`
+}
+
+type journal struct {
+	file *jsonfile.File[document]
+}
+
+func New(file string) Journal {
+	return &journal{file: jsonfile.New[document](file)}
+}
+
+func (j *journal) Append(record Record) error {
+	return j.file.Update(func(doc *document) error {
+		doc.Records = append(doc.Records, record)
+		return nil
+	})
+}
+
+func (j *journal) Records() ([]Record, error) {
+	doc, err := j.file.Load()
+	if os.IsNotExist(err) {
+		return []Record{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return doc.Records, nil
+}
+
+// IsUndone tells whether the logged entry has been undone later in the records
+func IsUndone(records []Record, logged Record) bool {
+	for _, record := range records {
+		if record.Action == ActionUndo && record.Profile == logged.Profile &&
+			record.EntryID == logged.EntryID && !record.Time.Before(logged.Time) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// LastLogged returns the most recent entry logged in the profile
+// which has not been undone yet
+func LastLogged(records []Record, profile string) (Record, bool) {
+	for i := len(records) - 1; i >= 0; i-- {
+		record := records[i]
+		if record.Action == ActionLog && record.Profile == profile && !IsUndone(records[i:], record) {
+			return record, true
+		}
+	}
+
+	return Record{}, false
+}
@@ -0,0 +1,49 @@
+package journal_test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/harnyk/teamjerk/internal/journal"
+	"github.com/ysmood/got"
+)
+
+func TestJournal(t *testing.T) {
+	g := got.T(t)
+	j := journal.New(filepath.Join(t.TempDir(), "state", "journal.json"))
+
+	records, err := j.Records()
+	g.E(err)
+	g.Len(records, 0)
+
+	_, ok := journal.LastLogged(records, "default")
+	g.False(ok)
+
+	start := time.Date(2023, 3, 1, 18, 0, 0, 0, time.UTC)
+	for i, record := range []journal.Record{
+		{Action: journal.ActionLog, Profile: "default", EntryID: 1},
+		{Action: journal.ActionLog, Profile: "default", EntryID: 2},
+		{Action: journal.ActionLog, Profile: "client-b", EntryID: 3},
+		{Action: journal.ActionUndo, Profile: "default", EntryID: 2},
+	} {
+		record.Time = start.Add(time.Duration(i) * time.Minute)
+		g.E(j.Append(record))
+	}
+
+	records, err = j.Records()
+	g.E(err)
+	g.Len(records, 4)
+	g.Eq(records[1].EntryID.String(), "2")
+
+	g.False(journal.IsUndone(records, records[0]))
+	g.True(journal.IsUndone(records, records[1]))
+
+	last, ok := journal.LastLogged(records, "default")
+	g.True(ok)
+	g.Eq(last.EntryID.String(), "1")
+
+	last, ok = journal.LastLogged(records, "client-b")
+	g.True(ok)
+	g.Eq(last.EntryID.String(), "3")
+}
@@ -0,0 +1,245 @@
+// Package jsonfile stores JSON documents under ~/.teamjerk safely:
+// writes are atomic (a temporary file is renamed over the target),
+// read-modify-write cycles are serialized with an advisory file lock,
+// and every document carries a schema version so that its format
+// can change without breaking the files written by older versions.
+package jsonfile
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// versionKey is the top-level field holding the schema version.
+// Documents written before versioning was introduced don't have it
+// and are treated as version 1.
+const versionKey = "version"
+
+// Migration upgrades the top-level fields of a document by one version
+type Migration func(fields map[string]json.RawMessage) error
+
+// File is a versioned JSON document of type T.
+// T must be marshaled as a JSON object.
+type File[T any] struct {
+	path string
+	// migrations[i] upgrades a document from version i+1 to i+2
+	migrations []Migration
+}
+
+// New returns the document stored in the given path.
+// The current schema version is len(migrations)+1.
+func New[T any](path string, migrations ...Migration) *File[T] {
+	return &File[T]{path: path, migrations: migrations}
+}
+
+func (f *File[T]) Path() string {
+	return f.path
+}
+
+// Load reads the document, upgrading it to the current version if needed
+func (f *File[T]) Load() (*T, error) {
+	fileContent, err := ioutil.ReadFile(f.path)
+	if err != nil {
+		return nil, err
+	}
+
+	v := new(T)
+	err = Unmarshal(fileContent, v, f.migrations)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", f.path, err)
+	}
+
+	return v, nil
+}
+
+// Save writes the document atomically while holding the lock
+func (f *File[T]) Save(v *T) error {
+	unlock, err := Lock(f.path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	return f.write(v)
+}
+
+// Update loads the document (or starts with the zero value if it doesn't exist),
+// lets fn modify it and writes it back, holding the lock all the time
+func (f *File[T]) Update(fn func(v *T) error) error {
+	unlock, err := Lock(f.path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	v, err := f.Load()
+	if os.IsNotExist(err) {
+		v, err = new(T), nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := fn(v); err != nil {
+		return err
+	}
+
+	return f.write(v)
+}
+
+func (f *File[T]) Exists() bool {
+	if _, err := os.Stat(f.path); os.IsNotExist(err) {
+		return false
+	}
+
+	return true
+}
+
+func (f *File[T]) Remove() error {
+	unlock, err := Lock(f.path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	err = os.Remove(f.path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
+
+func (f *File[T]) write(v *T) error {
+	content, err := Marshal(v, len(f.migrations)+1)
+	if err != nil {
+		return err
+	}
+
+	return WriteAtomic(f.path, content, 0600)
+}
+
+// Marshal encodes v as a JSON object with the schema version added
+func Marshal(v interface{}, version int) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := map[string]json.RawMessage{}
+	err = json.Unmarshal(data, &fields)
+	if err != nil {
+		return nil, fmt.Errorf("a versioned document must be a JSON object: %w", err)
+	}
+
+	fields[versionKey] = json.RawMessage(strconv.Itoa(version))
+
+	return json.MarshalIndent(fields, "", "  ")
+}
+
+// Unmarshal decodes a versioned JSON object into v,
+// running the migrations required to upgrade it to the current version
+func Unmarshal(data []byte, v interface{}, migrations []Migration) error {
+	fields := map[string]json.RawMessage{}
+	err := json.Unmarshal(data, &fields)
+	if err != nil {
+		return err
+	}
+
+	version := 1
+	if rawVersion, ok := fields[versionKey]; ok {
+		err = json.Unmarshal(rawVersion, &version)
+		if err != nil {
+			return fmt.Errorf("invalid version: %w", err)
+		}
+	}
+
+	currentVersion := len(migrations) + 1
+	if version > currentVersion {
+		return fmt.Errorf("the document version %d is not supported, please upgrade teamjerk", version)
+	}
+
+	for ; version < currentVersion; version++ {
+		err = migrations[version-1](fields)
+		if err != nil {
+			return fmt.Errorf("failed to upgrade from version %d: %w", version, err)
+		}
+	}
+
+	delete(fields, versionKey)
+
+	migrated, err := json.Marshal(fields)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(migrated, v)
+}
+
+// WriteAtomic writes the data to a temporary file in the same directory
+// and renames it over the target, so readers never see a partially written file
+func WriteAtomic(file string, data []byte, perm os.FileMode) error {
+	dir := filepath.Dir(file)
+
+	err := os.MkdirAll(dir, 0700)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(dir, filepath.Base(file)+".tmp*")
+	if err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+
+	// the temporary file is left behind only if the rename fails
+	defer os.Remove(tmpName)
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Chmod(tmpName, perm); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpName, file)
+}
+
+// Lock takes an exclusive advisory lock associated with the file
+// and returns the function releasing it.
+// The lock is kept in a separate <file>.lock file,
+// because the file itself is replaced on every write.
+func Lock(file string) (unlock func(), err error) {
+	err = os.MkdirAll(filepath.Dir(file), 0700)
+	if err != nil {
+		return nil, err
+	}
+
+	lockFile, err := os.OpenFile(file+".lock", os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := lockFileHandle(lockFile); err != nil {
+		lockFile.Close()
+		return nil, fmt.Errorf("failed to lock %s: %w", file, err)
+	}
+
+	return func() {
+		unlockFileHandle(lockFile)
+		lockFile.Close()
+	}, nil
+}
@@ -0,0 +1,74 @@
+package jsonfile_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/harnyk/teamjerk/internal/jsonfile"
+	"github.com/ysmood/got"
+)
+
+type document struct {
+	Name    string `json:"name"`
+	Counter int    `json:"counter"`
+}
+
+func TestFile_LoadUnversionedAndMigrate(t *testing.T) {
+	g := got.T(t)
+	path := filepath.Join(t.TempDir(), "doc.json")
+
+	// a document written before versioning, with the old field name
+	err := ioutil.WriteFile(path, []byte(`{"title": "legacy", "counter": 1}`), 0600)
+	g.Eq(err, nil)
+
+	renameTitle := func(fields map[string]json.RawMessage) error {
+		fields["name"] = fields["title"]
+		delete(fields, "title")
+		return nil
+	}
+
+	file := jsonfile.New[document](path, renameTitle)
+
+	doc, err := file.Load()
+	g.Eq(err, nil)
+	g.Eq(doc, &document{Name: "legacy", Counter: 1})
+
+	g.Eq(file.Save(doc), nil)
+
+	fileContent, err := ioutil.ReadFile(path)
+	g.Eq(err, nil)
+
+	fields := map[string]interface{}{}
+	g.Eq(json.Unmarshal(fileContent, &fields), nil)
+	g.Eq(fields, map[string]interface{}{"name": "legacy", "counter": 1.0, "version": 2.0})
+
+	// a newer document can't be read by an older version
+	_, err = jsonfile.New[document](path).Load()
+	g.Neq(err, nil)
+}
+
+func TestFile_ConcurrentUpdates(t *testing.T) {
+	g := got.T(t)
+	file := jsonfile.New[document](filepath.Join(t.TempDir(), "doc.json"))
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := file.Update(func(doc *document) error {
+				doc.Counter++
+				return nil
+			})
+			g.Eq(err, nil)
+		}()
+	}
+	wg.Wait()
+
+	doc, err := file.Load()
+	g.Eq(err, nil)
+	g.Eq(doc.Counter, 20)
+}
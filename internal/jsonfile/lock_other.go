@@ -0,0 +1,15 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd || windows)
+
+package jsonfile
+
+import "os"
+
+// advisory locks are not supported on this platform,
+// the writes are still atomic
+func lockFileHandle(f *os.File) error {
+	return nil
+}
+
+func unlockFileHandle(f *os.File) error {
+	return nil
+}
@@ -57,7 +57,7 @@ type Account struct {
 }
 
 type Installation struct {
-	ID                int               `json:"id"`
+	ID                ID                `json:"id"`
 	Name              string            `json:"name"`
 	URL               string            `json:"url"`
 	Region            string            `json:"region"`
@@ -73,7 +73,7 @@ type Installation struct {
 }
 
 type User struct {
-	ID        int    `json:"id"`
+	ID        ID     `json:"id"`
 	FirstName string `json:"firstName"`
 	LastName  string `json:"lastName"`
 	Email     string `json:"email"`
@@ -82,13 +82,13 @@ type User struct {
 }
 
 type Company struct {
-	ID   int    `json:"id"`
+	ID   ID     `json:"id"`
 	Name string `json:"name"`
 	Logo string `json:"logo"`
 }
 
 type ProjectTemplate struct {
-	ID   int    `json:"id"`
+	ID   ID     `json:"id"`
 	Name string `json:"name"`
 }
 
@@ -0,0 +1,345 @@
+package twapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+/*
+
+A cassette is a directory of the recorded interactions, one file each,
+numbered in the order they were made:
+
+0001-get-me.json
+{
+    "request": {
+        "method": "GET",
+        "url": "/me.json"
+    },
+    "response": {
+        "status_code": 200,
+        "headers": {
+            "Content-Type": ["application/json; charset=utf-8"]
+        },
+        "body": {"person": {"first-name": "[REDACTED]", ...}, "STATUS": "OK"}
+    }
+}
+
+The host is not recorded, so the cassette is replayed whatever the installation is.
+*/
+
+// ErrNotRecorded is returned in the replay mode for the requests missing in the cassette
+var ErrNotRecorded = errors.New("no recorded response")
+
+// sanitizedHost replaces the hosts of the URLs found in the recorded bodies,
+// which contain the name of the company
+const sanitizedHost = "example.teamwork.com"
+
+// personalFields are the JSON fields redacted in the recordings
+// in addition to the credentials
+var personalFields = []string{
+	"email", "email-address", "user-name",
+	"firstName", "first-name", "firstname",
+	"lastName", "last-name", "lastname",
+	"avatar", "avatar-url", "phone-number-mobile", "phone-number-office",
+}
+
+// recordedHeaders are the response headers kept in the recordings,
+// the rest (e.g. the request IDs) are dropped
+var recordedHeaders = []string{"Content-Type", "Set-Cookie", "X-Page", "X-Pages", "Retry-After"}
+
+type interaction struct {
+	Request  recordedRequest  `json:"request"`
+	Response recordedResponse `json:"response"`
+}
+
+type recordedRequest struct {
+	Method string `json:"method"`
+	// URL is the path with the query
+	URL  string          `json:"url"`
+	Body json.RawMessage `json:"body,omitempty"`
+}
+
+type recordedResponse struct {
+	StatusCode int             `json:"status_code"`
+	Headers    http.Header     `json:"headers,omitempty"`
+	Body       json.RawMessage `json:"body,omitempty"`
+	// BodyText is set instead of Body if the body is not JSON
+	BodyText string `json:"body_text,omitempty"`
+}
+
+// WithRecorder saves every request and response to the cassette directory,
+// so they can be replayed with WithReplay.
+// The credentials and the personal data are redacted.
+// If the interaction can't be saved, the request fails.
+func WithRecorder(dir string) Option {
+	return func(c *client) {
+		c.http.SetTransport(&recordingTransport{
+			next: c.http.GetClient().Transport,
+			dir:  dir,
+		})
+	}
+}
+
+// WithReplay serves the requests from the cassette directory recorded with WithRecorder,
+// without connecting to Teamwork. The interactions with the same method and URL
+// are replayed in the recorded order, the last one is repeated when they run out.
+func WithReplay(dir string) Option {
+	return func(c *client) {
+		c.http.SetTransport(&replayTransport{dir: dir})
+	}
+}
+
+type recordingTransport struct {
+	next http.RoundTripper
+	dir  string
+
+	mu sync.Mutex
+}
+
+func (t *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var requestBody []byte
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody != nil {
+		if body, err := req.GetBody(); err == nil && body != nil {
+			requestBody, _ = io.ReadAll(body)
+			body.Close()
+		}
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// the body is read here, so it is replaced with a copy for the caller
+	responseBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(responseBody))
+
+	recorded := interaction{
+		Request: recordedRequest{
+			Method: req.Method,
+			URL:    req.URL.RequestURI(),
+			Body:   sanitizeBody(requestBody),
+		},
+		Response: recordedResponse{
+			StatusCode: resp.StatusCode,
+			Headers:    sanitizeHeaders(resp.Header),
+			Body:       sanitizeBody(responseBody),
+		},
+	}
+	if recorded.Response.Body == nil {
+		recorded.Response.BodyText = string(responseBody)
+	}
+
+	if err := t.save(&recorded); err != nil {
+		return nil, fmt.Errorf("failed to record %s %s: %w", req.Method, req.URL.Path, err)
+	}
+
+	return resp, nil
+}
+
+var nonAlphanumeric = regexp.MustCompile(`[^a-z0-9]+`)
+
+func (t *recordingTransport) save(recorded *interaction) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if err := os.MkdirAll(t.dir, 0700); err != nil {
+		return err
+	}
+
+	// the files of the earlier runs are kept, the numbering continues
+	existing, err := filepath.Glob(filepath.Join(t.dir, "*.json"))
+	if err != nil {
+		return err
+	}
+
+	path, _, _ := strings.Cut(recorded.Request.URL, "?")
+	name := strings.Trim(nonAlphanumeric.ReplaceAllString(strings.ToLower(strings.TrimSuffix(path, ".json")), "-"), "-")
+	file := fmt.Sprintf("%04d-%s-%s.json", len(existing)+1, strings.ToLower(recorded.Request.Method), name)
+
+	// the URLs are easier to read with '&' not escaped
+	data := &bytes.Buffer{}
+	encoder := json.NewEncoder(data)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(recorded); err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join(t.dir, file), data.Bytes(), 0600)
+}
+
+// sanitizeHeaders keeps only the recordedHeaders and redacts the cookies
+func sanitizeHeaders(header http.Header) http.Header {
+	sanitized := http.Header{}
+
+	for _, name := range recordedHeaders {
+		for _, value := range header.Values(name) {
+			sanitized.Add(name, redactHeader(name, value))
+		}
+	}
+
+	return sanitized
+}
+
+// sanitizeBody returns the JSON body with the credentials and the personal data redacted,
+// or nil if the body is empty or not JSON
+func sanitizeBody(data []byte) json.RawMessage {
+	var document interface{}
+	if len(data) == 0 || json.Unmarshal(data, &document) != nil {
+		return nil
+	}
+
+	sensitiveFields := append(append([]string{}, sensitiveRequestFields...), personalFields...)
+
+	sanitized, err := json.Marshal(sanitizeValue(document, sensitiveFields))
+	if err != nil {
+		return nil
+	}
+
+	return sanitized
+}
+
+// sanitizeValue redacts the sensitive fields and replaces the hosts of the URLs
+func sanitizeValue(value interface{}, sensitiveFields []string) interface{} {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		for key, field := range value {
+			if _, isString := field.(string); isString && isSensitiveField(key, sensitiveFields) {
+				value[key] = redacted
+			} else {
+				value[key] = sanitizeValue(field, sensitiveFields)
+			}
+		}
+	case []interface{}:
+		for i, item := range value {
+			value[i] = sanitizeValue(item, sensitiveFields)
+		}
+	case string:
+		if strings.HasPrefix(value, "http://") || strings.HasPrefix(value, "https://") {
+			if u, err := url.Parse(value); err == nil {
+				u.Host = sanitizedHost
+				return u.String()
+			}
+		}
+	}
+
+	return value
+}
+
+type replayTransport struct {
+	dir string
+
+	mu sync.Mutex
+	// interactions are the recorded ones by the method and URL,
+	// loaded on the first request
+	interactions map[string][]*interaction
+}
+
+func (t *replayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.interactions == nil {
+		if err := t.load(); err != nil {
+			return nil, err
+		}
+	}
+
+	key := replayKey(req.Method, req.URL.RequestURI())
+
+	recorded := t.interactions[key]
+	if len(recorded) == 0 {
+		return nil, fmt.Errorf("%w for %s in %s", ErrNotRecorded, key, t.dir)
+	}
+
+	// the last one is kept to be repeated
+	next := recorded[0]
+	if len(recorded) > 1 {
+		t.interactions[key] = recorded[1:]
+	}
+
+	body := []byte(next.Response.BodyText)
+	if next.Response.Body != nil {
+		body = next.Response.Body
+	}
+
+	header := next.Response.Headers.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", next.Response.StatusCode, http.StatusText(next.Response.StatusCode)),
+		StatusCode:    next.Response.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+func (t *replayTransport) load() error {
+	files, err := filepath.Glob(filepath.Join(t.dir, "*.json"))
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("no recordings found in %s", t.dir)
+	}
+	sort.Strings(files)
+
+	t.interactions = map[string][]*interaction{}
+
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+
+		recorded := &interaction{}
+		if err := json.Unmarshal(data, recorded); err != nil {
+			return fmt.Errorf("invalid recording %s: %w", file, err)
+		}
+
+		key := replayKey(recorded.Request.Method, recorded.Request.URL)
+		t.interactions[key] = append(t.interactions[key], recorded)
+	}
+
+	return nil
+}
+
+// replayKey identifies the request regardless of the order of the query parameters
+func replayKey(method, requestURI string) string {
+	path, query, _ := strings.Cut(requestURI, "?")
+
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return method + " " + requestURI
+	}
+
+	if len(values) == 0 {
+		return method + " " + path
+	}
+
+	return method + " " + path + "?" + values.Encode()
+}
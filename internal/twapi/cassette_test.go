@@ -0,0 +1,73 @@
+package twapi_test
+
+import (
+	"context"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/harnyk/teamjerk/internal/twapi"
+	"github.com/harnyk/teamjerk/internal/twfake"
+	"github.com/ysmood/got"
+)
+
+func TestRecorderAndReplay(t *testing.T) {
+	g := got.T(t)
+	ctx := context.Background()
+	cassette := t.TempDir()
+
+	server := httptest.NewServer(twfake.NewServer())
+
+	recorder := twapi.NewClient(twapi.WithLaunchpadURL(server.URL), twapi.WithRecorder(cassette))
+
+	accounts, err := recorder.GetAccountsToLogIn(ctx, twfake.Email, twfake.Password)
+	g.E(err)
+	auth, err := recorder.LogIn(ctx, accounts.Accounts[0].Installation.ApiEndPoint, twfake.Email, twfake.Password)
+	g.E(err)
+	_, err = recorder.GetMe(ctx, auth)
+	g.E(err)
+	tasks, err := recorder.GetTasks(ctx, auth)
+	g.E(err)
+
+	server.Close()
+
+	files, err := filepath.Glob(filepath.Join(cassette, "*.json"))
+	g.E(err)
+	g.Eq(filepath.Base(files[0]), "0001-post-launchpad-v1-accounts.json")
+	g.Len(files, 4)
+
+	host := strings.TrimPrefix(server.URL, "http://")
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		g.E(err)
+
+		for _, secret := range []string{twfake.Email, twfake.Password, twfake.FirstName, auth.Token, host} {
+			g.Desc("%s contains %s", file, secret).Eq(strings.Contains(string(data), secret), false)
+		}
+	}
+
+	replay := twapi.NewClient(twapi.WithReplay(cassette), twapi.WithRetries(0, time.Millisecond))
+
+	accounts, err = replay.GetAccountsToLogIn(ctx, "anyone@example.com", "any password")
+	g.E(err)
+	g.Eq(accounts.Accounts[0].Installation.ApiEndPoint, "http://example.teamwork.com/")
+
+	replayedAuth, err := replay.LogIn(ctx, accounts.Accounts[0].Installation.ApiEndPoint, "anyone@example.com", "any password")
+	g.E(err)
+	g.Eq(replayedAuth.Token, "[REDACTED]")
+
+	me, err := replay.GetMe(ctx, replayedAuth)
+	g.E(err)
+	g.Eq(me.Person.ID, twapi.ID(twfake.UserID))
+	g.Eq(me.Person.FirstName, "[REDACTED]")
+
+	replayedTasks, err := replay.GetTasks(ctx, replayedAuth)
+	g.E(err)
+	g.Eq(replayedTasks, tasks)
+
+	_, err = replay.GetProjects(ctx, replayedAuth)
+	g.Has(err.Error(), "no recorded response for GET /projects/api/v3/projects.json")
+}
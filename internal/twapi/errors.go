@@ -0,0 +1,155 @@
+package twapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// The error classes matched by APIError with errors.Is
+var (
+	// ErrUnauthorized is returned when the server rejects the credentials,
+	// e.g. because the session token has expired or has been revoked.
+	ErrUnauthorized = errors.New("unauthorized")
+	// ErrForbidden is returned when the user has no permission for the operation
+	ErrForbidden = errors.New("forbidden")
+	ErrNotFound  = errors.New("not found")
+	// ErrValidation is returned when the request is rejected as invalid,
+	// e.g. the task is locked or the date is wrong
+	ErrValidation = errors.New("validation failed")
+	// ErrServer is returned when the server fails or is overloaded
+	// and the retries didn't help
+	ErrServer = errors.New("server error")
+)
+
+/*
+
+Examples of the error responses.
+
+Legacy API:
+
+{
+    "MESSAGE": "You don't have permission to log time on this task",
+    "STATUS": "Error"
+}
+
+API v3:
+
+{
+    "errors": [
+        {
+            "title": "Bad Request",
+            "detail": "date is invalid",
+            "id": "",
+            "code": ""
+        }
+    ]
+}
+
+Launchpad:
+
+{
+    "status": "error",
+    "message": "Invalid email or password"
+}
+*/
+
+type errorResponse struct {
+	LegacyMessage string `json:"MESSAGE"`
+	Message       string `json:"message"`
+	Errors        []struct {
+		Title  string `json:"title"`
+		Detail string `json:"detail"`
+	} `json:"errors"`
+}
+
+// APIError is returned when the API responds with an unexpected status
+type APIError struct {
+	StatusCode int
+	Method     string
+	Endpoint   string
+	// Messages are the error messages returned by Teamwork, if any
+	Messages []string
+}
+
+func (e *APIError) Error() string {
+	message := fmt.Sprintf("%s %s: status code: %d", e.Method, e.Endpoint, e.StatusCode)
+
+	if len(e.Messages) > 0 {
+		message += ": " + strings.Join(e.Messages, "; ")
+	}
+
+	return message
+}
+
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized
+	case ErrForbidden:
+		return e.StatusCode == http.StatusForbidden
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrValidation:
+		return e.StatusCode == http.StatusBadRequest ||
+			e.StatusCode == http.StatusConflict ||
+			e.StatusCode == http.StatusUnprocessableEntity
+	case ErrServer:
+		return e.StatusCode == http.StatusTooManyRequests ||
+			e.StatusCode >= http.StatusInternalServerError
+	}
+
+	return false
+}
+
+// checkStatus returns an APIError if the response status
+// is not one of the expected ones
+func checkStatus(resp *resty.Response, expected ...int) error {
+	for _, status := range expected {
+		if resp.StatusCode() == status {
+			return nil
+		}
+	}
+
+	endpoint := resp.Request.URL
+	if i := strings.IndexByte(endpoint, '?'); i >= 0 {
+		endpoint = endpoint[:i]
+	}
+
+	return &APIError{
+		StatusCode: resp.StatusCode(),
+		Method:     resp.Request.Method,
+		Endpoint:   endpoint,
+		Messages:   parseErrorMessages(resp.Body()),
+	}
+}
+
+func parseErrorMessages(body []byte) []string {
+	response := &errorResponse{}
+	if err := json.Unmarshal(body, response); err != nil {
+		return nil
+	}
+
+	messages := []string{}
+
+	if response.LegacyMessage != "" {
+		messages = append(messages, response.LegacyMessage)
+	}
+	if response.Message != "" {
+		messages = append(messages, response.Message)
+	}
+	for _, e := range response.Errors {
+		switch {
+		case e.Detail != "":
+			messages = append(messages, e.Detail)
+		case e.Title != "":
+			messages = append(messages, e.Title)
+		}
+	}
+
+	return messages
+}
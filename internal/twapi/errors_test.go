@@ -0,0 +1,107 @@
+package twapi_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/harnyk/teamjerk/internal/twapi"
+	"github.com/ysmood/got"
+)
+
+func TestAPIError_Messages(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		messages []string
+	}{
+		{
+			name:     "legacy API",
+			body:     `{"MESSAGE": "You don't have permission to log time on this task", "STATUS": "Error"}`,
+			messages: []string{"You don't have permission to log time on this task"},
+		},
+		{
+			name:     "launchpad",
+			body:     `{"status": "error", "message": "Invalid email or password"}`,
+			messages: []string{"Invalid email or password"},
+		},
+		{
+			name:     "API v3",
+			body:     `{"errors": [{"title": "Bad Request", "detail": "date is invalid"}, {"title": "Conflict"}]}`,
+			messages: []string{"date is invalid", "Conflict"},
+		},
+		{
+			name:     "no messages",
+			body:     `{}`,
+			messages: []string{},
+		},
+		{
+			name:     "not JSON",
+			body:     `<html>Bad Request</html>`,
+			messages: nil,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g := got.T(t)
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(test.body))
+			}))
+			defer server.Close()
+
+			_, err := twapi.NewClient().GetMe(context.Background(), &twapi.AuthData{APIEndPoint: server.URL + "/"})
+
+			var apiErr *twapi.APIError
+			g.True(errors.As(err, &apiErr))
+			g.Eq(apiErr.StatusCode, http.StatusBadRequest)
+			g.Eq(apiErr.Method, http.MethodGet)
+			g.Eq(apiErr.Messages, test.messages)
+		})
+	}
+}
+
+func TestAPIError_Is(t *testing.T) {
+	sentinels := []error{
+		twapi.ErrUnauthorized,
+		twapi.ErrForbidden,
+		twapi.ErrNotFound,
+		twapi.ErrValidation,
+		twapi.ErrServer,
+	}
+
+	tests := []struct {
+		statusCode int
+		// expected is the sentinel matched by the status code, nil if none
+		expected error
+	}{
+		{http.StatusUnauthorized, twapi.ErrUnauthorized},
+		{http.StatusForbidden, twapi.ErrForbidden},
+		{http.StatusNotFound, twapi.ErrNotFound},
+		{http.StatusBadRequest, twapi.ErrValidation},
+		{http.StatusConflict, twapi.ErrValidation},
+		{http.StatusUnprocessableEntity, twapi.ErrValidation},
+		{http.StatusTooManyRequests, twapi.ErrServer},
+		{http.StatusInternalServerError, twapi.ErrServer},
+		{http.StatusBadGateway, twapi.ErrServer},
+		{http.StatusServiceUnavailable, twapi.ErrServer},
+		{http.StatusMethodNotAllowed, nil},
+		{http.StatusFound, nil},
+	}
+
+	for _, test := range tests {
+		t.Run(http.StatusText(test.statusCode), func(t *testing.T) {
+			g := got.T(t)
+			err := error(&twapi.APIError{StatusCode: test.statusCode})
+
+			for _, sentinel := range sentinels {
+				g.Desc("%d matched by %v", test.statusCode, sentinel).
+					Eq(errors.Is(err, sentinel), sentinel == test.expected)
+			}
+		})
+	}
+}
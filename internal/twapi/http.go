@@ -0,0 +1,165 @@
+package twapi
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+)
+
+const (
+	defaultTimeout      = 30 * time.Second
+	defaultRetryCount   = 3
+	defaultRetryWait    = 500 * time.Millisecond
+	defaultRetryMaxWait = 30 * time.Second
+)
+
+type Option func(c *client)
+
+// WithTimeout sets the timeout of a single request attempt
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *client) {
+		c.http.SetTimeout(timeout)
+	}
+}
+
+// WithRetries sets how many times a failed request is retried,
+// and the initial wait time of the exponential backoff
+func WithRetries(count int, wait time.Duration) Option {
+	return func(c *client) {
+		c.http.
+			SetRetryCount(count).
+			SetRetryWaitTime(wait)
+	}
+}
+
+// WithProxy sends all the requests through the proxy,
+// instead of the one set by the HTTPS_PROXY environment variable
+func WithProxy(proxyURL *url.URL) Option {
+	return func(c *client) {
+		c.transport.Proxy = http.ProxyURL(proxyURL)
+	}
+}
+
+// WithRootCAs sets the certificate authorities trusted by the client,
+// e.g. those of a corporate proxy inspecting the TLS traffic
+func WithRootCAs(pool *x509.CertPool) Option {
+	return func(c *client) {
+		c.transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+	}
+}
+
+// LoadCABundle returns the system certificate authorities
+// together with the ones of the PEM file
+func LoadCABundle(file string) (*x509.CertPool, error) {
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+
+	pem, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates found in %s", file)
+	}
+
+	return pool, nil
+}
+
+func newTransport() *http.Transport {
+	return &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   10 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          10,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+}
+
+func newHTTPClient(transport *http.Transport) *resty.Client {
+	return resty.New().
+		SetTransport(transport).
+		SetTimeout(defaultTimeout).
+		SetRetryCount(defaultRetryCount).
+		SetRetryWaitTime(defaultRetryWait).
+		SetRetryMaxWaitTime(defaultRetryMaxWait).
+		AddRetryCondition(shouldRetry).
+		SetRetryAfter(retryAfter)
+}
+
+// shouldRetry decides whether a failed attempt can be repeated.
+// Idempotent requests are retried on network errors, 429 and 5xx.
+// Other requests (e.g. logging time) are retried only if the server
+// surely hasn't processed them: on 429 or when the connection couldn't be established.
+func shouldRetry(resp *resty.Response, err error) bool {
+	if resp == nil || resp.Request == nil {
+		return false
+	}
+
+	idempotent := isIdempotent(resp.Request.Method)
+
+	if err != nil {
+		// replaying the same cassette again won't help
+		if errors.Is(err, ErrNotRecorded) {
+			return false
+		}
+
+		var opErr *net.OpError
+		if errors.As(err, &opErr) && opErr.Op == "dial" {
+			return true
+		}
+
+		return idempotent
+	}
+
+	if resp.StatusCode() == http.StatusTooManyRequests {
+		return true
+	}
+
+	return idempotent && resp.StatusCode() >= http.StatusInternalServerError
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions,
+		http.MethodPut, http.MethodDelete:
+		return true
+	}
+
+	return false
+}
+
+// retryAfter returns the wait time requested by the server in the Retry-After header.
+// Zero means the default exponential backoff is used.
+func retryAfter(_ *resty.Client, resp *resty.Response) (time.Duration, error) {
+	header := resp.Header().Get("Retry-After")
+	if header == "" {
+		return 0, nil
+	}
+
+	if seconds, err := strconv.Atoi(header); err == nil {
+		return time.Duration(seconds) * time.Second, nil
+	}
+
+	if date, err := http.ParseTime(header); err == nil {
+		return time.Until(date), nil
+	}
+
+	return 0, nil
+}
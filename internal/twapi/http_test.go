@@ -0,0 +1,107 @@
+package twapi_test
+
+import (
+	"context"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/harnyk/teamjerk/internal/twapi"
+	"github.com/ysmood/got"
+)
+
+func TestClient_RetriesIdempotentRequests(t *testing.T) {
+	var attempts int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch atomic.AddInt32(&attempts, 1) {
+		case 1:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case 2:
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"person": {"id": "42"}, "status": "OK"}`))
+		}
+	}))
+	defer server.Close()
+
+	tw := twapi.NewClient(twapi.WithRetries(3, time.Millisecond))
+
+	me, err := tw.GetMe(context.Background(), &twapi.AuthData{APIEndPoint: server.URL + "/"})
+
+	got.T(t).Eq(err, nil)
+	got.T(t).Eq(me.Person.ID, twapi.ID(42))
+	got.T(t).Eq(atomic.LoadInt32(&attempts), int32(3))
+}
+
+func TestClient_DoesNotRetryLoggingTimeOnServerError(t *testing.T) {
+	var attempts int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	tw := twapi.NewClient(twapi.WithRetries(3, time.Millisecond))
+
+	_, err := tw.LogTime(context.Background(), &twapi.AuthData{APIEndPoint: server.URL + "/"}, &twapi.LogtimeRequestWithProjectID{ProjectID: 1})
+
+	got.T(t).Neq(err, nil)
+	got.T(t).Eq(atomic.LoadInt32(&attempts), int32(1))
+}
+
+func TestClient_StopsRetryingWhenContextIsDone(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	tw := twapi.NewClient(twapi.WithRetries(3, time.Hour))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := tw.GetMe(ctx, &twapi.AuthData{APIEndPoint: server.URL + "/"})
+
+	got.T(t).True(errors.Is(err, context.DeadlineExceeded))
+	got.T(t).Lt(time.Since(start), 5*time.Second)
+}
+
+func TestClient_UsesAPIEndPointOverrideAndCABundle(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"person": {"id": "42"}, "status": "OK"}`))
+	}))
+	defer server.Close()
+
+	caBundle := filepath.Join(t.TempDir(), "ca.pem")
+	err := os.WriteFile(caBundle, pem.EncodeToMemory(&pem.Block{
+		Type:  "CERTIFICATE",
+		Bytes: server.Certificate().Raw,
+	}), 0600)
+	got.T(t).Eq(err, nil)
+
+	pool, err := twapi.LoadCABundle(caBundle)
+	got.T(t).Eq(err, nil)
+
+	tw := twapi.NewClient(
+		twapi.WithAPIEndPoint(server.URL),
+		twapi.WithRootCAs(pool),
+		twapi.WithRetries(0, time.Millisecond),
+	)
+
+	me, err := tw.GetMe(context.Background(), &twapi.AuthData{APIEndPoint: "https://example.invalid/"})
+
+	got.T(t).Eq(err, nil)
+	got.T(t).Eq(me.Person.ID, twapi.ID(42))
+}
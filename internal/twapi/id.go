@@ -0,0 +1,54 @@
+package twapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ID identifies any Teamwork entity: a user, a project, a task, a time entry, etc.
+// The legacy API returns the IDs as strings and API v3 as numbers,
+// both are accepted when unmarshaling.
+type ID uint64
+
+func (id *ID) UnmarshalJSON(b []byte) error {
+	s := string(b)
+
+	if s == "null" {
+		*id = 0
+		return nil
+	}
+
+	if strings.HasPrefix(s, `"`) {
+		if err := json.Unmarshal(b, &s); err != nil {
+			return err
+		}
+		if s == "" {
+			*id = 0
+			return nil
+		}
+	}
+
+	parsed, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid ID %s", b)
+	}
+	*id = ID(parsed)
+
+	return nil
+}
+
+func (id ID) String() string {
+	return strconv.FormatUint(uint64(id), 10)
+}
+
+// ParseID parses the ID given by the user, e.g. as a command line argument
+func ParseID(s string) (ID, error) {
+	id, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid ID %q", s)
+	}
+
+	return ID(id), nil
+}
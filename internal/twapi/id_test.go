@@ -0,0 +1,35 @@
+package twapi_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/harnyk/teamjerk/internal/twapi"
+	"github.com/ysmood/got"
+)
+
+func TestID_UnmarshalJSON(t *testing.T) {
+	g := got.T(t)
+
+	var ids []twapi.ID
+	g.E(json.Unmarshal([]byte(`[42, "43", "", null]`), &ids))
+	g.Eq(ids, []twapi.ID{42, 43, 0, 0})
+
+	g.Err(json.Unmarshal([]byte(`["abc"]`), &ids))
+	g.Err(json.Unmarshal([]byte(`[-1]`), &ids))
+
+	data, err := json.Marshal(twapi.ID(42))
+	g.E(err)
+	g.Eq(string(data), "42")
+}
+
+func TestParseID(t *testing.T) {
+	g := got.T(t)
+
+	id, err := twapi.ParseID("42")
+	g.E(err)
+	g.Eq(id, twapi.ID(42))
+
+	_, err = twapi.ParseID("task-42")
+	g.Eq(err.Error(), `invalid ID "task-42"`)
+}
@@ -0,0 +1,102 @@
+package twapi
+
+import (
+	"strconv"
+
+	"github.com/go-resty/resty/v2"
+)
+
+const defaultPageSize = 250
+
+// Pager iterates over the pages of a paginated list,
+// fetching the next page only when it is requested:
+//
+//	pager := tw.TasksPager(auth)
+//	for pager.Next() {
+//		for _, task := range pager.Items() {
+//			...
+//		}
+//	}
+//	if err := pager.Err(); err != nil {
+//		...
+//	}
+type Pager[T any] struct {
+	fetch func(page int) (items []T, hasMore bool, err error)
+
+	page    int
+	items   []T
+	hasMore bool
+	err     error
+}
+
+func newPager[T any](fetch func(page int) ([]T, bool, error)) *Pager[T] {
+	return &Pager[T]{fetch: fetch, hasMore: true}
+}
+
+// Next fetches the next page.
+// Returns false if there are no more pages or the request has failed.
+func (p *Pager[T]) Next() bool {
+	if !p.hasMore || p.err != nil {
+		return false
+	}
+
+	p.page++
+	p.items, p.hasMore, p.err = p.fetch(p.page)
+
+	return p.err == nil
+}
+
+// Items returns the items of the current page
+func (p *Pager[T]) Items() []T {
+	return p.items
+}
+
+// Page returns the number of the current page, starting from 1
+func (p *Pager[T]) Page() int {
+	return p.page
+}
+
+// Err returns the error which stopped the iteration, if any
+func (p *Pager[T]) Err() error {
+	return p.err
+}
+
+// All fetches the remaining pages and returns all their items
+func (p *Pager[T]) All() ([]T, error) {
+	all := []T{}
+
+	for p.Next() {
+		all = append(all, p.Items()...)
+	}
+
+	return all, p.Err()
+}
+
+func pageParams(page, pageSize int) map[string]string {
+	return map[string]string{
+		"page":     strconv.Itoa(page),
+		"pageSize": strconv.Itoa(pageSize),
+	}
+}
+
+// Meta is the metadata of the paginated API v3 responses
+type Meta struct {
+	Page struct {
+		PageOffset int  `json:"pageOffset"`
+		PageSize   int  `json:"pageSize"`
+		Count      int  `json:"count"`
+		HasMore    bool `json:"hasMore"`
+	} `json:"page"`
+}
+
+// hasMorePages tells whether there is a page after the current one.
+// The legacy API reports the number of pages in the X-Pages header,
+// if it is missing, the response is considered to be the only page.
+func hasMorePages(resp *resty.Response, page int) bool {
+	pages, err := strconv.Atoi(resp.Header().Get("X-Pages"))
+	if err != nil {
+		return false
+	}
+
+	return page < pages
+}
@@ -0,0 +1,50 @@
+package twapi_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/harnyk/teamjerk/internal/twapi"
+	"github.com/ysmood/got"
+)
+
+func TestClient_GetTasksFollowsPages(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{
+			"tasks": [{"id": %d, "name": "Task %d", "tasklistId": 10}],
+			"included": {
+				"tasklists": {"10": {"id": 10, "name": "Backlog", "projectId": 20}},
+				"projects": {"20": {"id": 20, "name": "Website"}}
+			},
+			"meta": {"page": {"hasMore": %t}}
+		}`, page, page, page < 3)
+	}))
+	defer server.Close()
+
+	tw := twapi.NewClient()
+	auth := &twapi.AuthData{APIEndPoint: server.URL + "/"}
+
+	tasks, err := tw.GetTasks(context.Background(), auth)
+	got.T(t).Eq(err, nil)
+	got.T(t).Len(tasks.Tasks, 3)
+	got.T(t).Eq(tasks.Tasks[2].Name, "Task 3")
+	got.T(t).Eq(tasks.Tasks[2].ProjectID, twapi.ID(20))
+	got.T(t).Eq(tasks.Tasks[2].ProjectName, "Website")
+	got.T(t).Eq(tasks.Tasks[2].TasklistName, "Backlog")
+
+	pager := tw.TasksPager(context.Background(), auth)
+	pages := []int{}
+	for pager.Next() {
+		pages = append(pages, pager.Page())
+		got.T(t).Len(pager.Items(), 1)
+	}
+	got.T(t).Eq(pager.Err(), nil)
+	got.T(t).Eq(pages, []int{1, 2, 3})
+}
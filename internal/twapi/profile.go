@@ -9,10 +9,10 @@ type ProfilePerson struct {
 	AvatarURL      string `json:"avatar-url"`
 	EmailAddress   string `json:"email-address"`
 	UserName       string `json:"user-name"`
-	ID             string `json:"id"`
+	ID             ID     `json:"id"`
 	CompanyName    string `json:"company-name"`
-	InstallationID string `json:"installationId"`
-	CompanyID      string `json:"companyId"`
+	InstallationID ID     `json:"installationId"`
+	CompanyID      ID     `json:"companyId"`
 	LastName       string `json:"last-name"`
 	FirstName      string `json:"first-name"`
 	LengthOfDay    string `json:"lengthOfDay"`
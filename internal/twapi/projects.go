@@ -1,26 +1,70 @@
 package twapi
 
+/*
+
+Example of ProjectsResponse (API v3, projects/api/v3/projects.json?include=companies):
+
+{
+    "projects": [
+        {
+            "id": 548295,
+            "name": "Website",
+            "description": "",
+            "status": "active",
+            "companyId": 97396
+        }
+    ],
+    "included": {
+        "companies": {
+            "97396": {
+                "id": 97396,
+                "name": "Skelia sarl"
+            }
+        }
+    },
+    "meta": {
+        "page": {
+            "pageOffset": 0,
+            "pageSize": 250,
+            "count": 1,
+            "hasMore": false
+        }
+    }
+}
+*/
+
 type ProjectsResponse struct {
-	Projects []Project `json:"projects"`
-	Status   string    `json:"STATUS"`
+	Projects []Project       `json:"projects"`
+	Included ProjectIncluded `json:"included"`
+	Meta     Meta            `json:"meta"`
+}
+
+// ProjectIncluded is the data sideloaded with the projects, by ID
+type ProjectIncluded struct {
+	Companies map[string]Company `json:"companies"`
 }
 
 type Project struct {
-	Category    ProjectCategory `json:"category"`
-	Company     ProjectCompany  `json:"company"`
-	Description string          `json:"description"`
-	ID          string          `json:"id"`
-	IsBillable  bool            `json:"isBillable"`
-	Name        string          `json:"name"`
+	ID          ID     `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Status      string `json:"status"`
+	CompanyID   ID     `json:"companyId"`
+
+	// CompanyName is resolved from the included companies
+	CompanyName string `json:"companyName,omitempty"`
 }
 
-type ProjectCompany struct {
-	ID   string `json:"id"`
-	Name string `json:"name"`
+// resolveIncluded fills the fields of the projects taken from the included data
+func (pr *ProjectsResponse) resolveIncluded() {
+	for i := range pr.Projects {
+		project := &pr.Projects[i]
+		project.CompanyName = pr.Included.Companies[project.CompanyID.String()].Name
+	}
 }
 
 type ProjectCategory struct {
 	Color string `json:"color"`
-	ID    string `json:"id"`
+	ID    ID     `json:"id"`
 	Name  string `json:"name"`
 }
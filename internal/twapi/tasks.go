@@ -1,35 +1,122 @@
 package twapi
 
+import "sort"
+
+/*
+
+Example of TasksResponse
+(API v3, projects/api/v3/tasks.json?include=projects,tasklists,tags,parentTasks):
+
+{
+    "tasks": [
+        {
+            "id": 26658918,
+            "name": "Code review",
+            "description": "",
+            "status": "new",
+            "tasklistId": 2114105,
+            "parentTaskId": 26658900,
+            "tagIds": [31042],
+            "estimateMinutes": 120
+        }
+    ],
+    "included": {
+        "projects": {
+            "548295": {"id": 548295, "name": "Website"}
+        },
+        "tasklists": {
+            "2114105": {"id": 2114105, "name": "Sprint 12", "projectId": 548295}
+        },
+        "tags": {
+            "31042": {"id": 31042, "name": "backend", "color": "#4461d7"}
+        },
+        "parentTasks": {
+            "26658900": {"id": 26658900, "name": "Checkout"}
+        }
+    },
+    "meta": {
+        "page": {"pageOffset": 0, "pageSize": 250, "count": 1, "hasMore": false}
+    }
+}
+*/
+
 type TasksResponse struct {
-	Tasks  []Task `json:"todo-items"`
-	Status string `json:"STATUS"`
+	Tasks    []Task       `json:"tasks"`
+	Included TaskIncluded `json:"included"`
+	Meta     Meta         `json:"meta"`
+}
+
+// TaskIncluded is the data sideloaded with the tasks, by ID
+type TaskIncluded struct {
+	Projects    map[string]Project  `json:"projects"`
+	Tasklists   map[string]Tasklist `json:"tasklists"`
+	Tags        map[string]Tag      `json:"tags"`
+	ParentTasks map[string]Task     `json:"parentTasks"`
 }
 
 type Task struct {
-	ID           uint64 `json:"id"`
-	Content      string `json:"content"`
-	ProjectID    uint64 `json:"project-id"`
-	ProjectName  string `json:"project-name"`
-	TodoListID   uint64 `json:"todo-list-id"`
-	TodoListName string `json:"todo-list-name"`
-	CompanyName  string `json:"company-name"`
-	CompanyID    uint64 `json:"company-id"`
-	TimeIsLogged string `json:"timeIsLogged"`
-	CanLogTime   bool   `json:"canLogTime"`
+	ID              ID     `json:"id"`
+	Name            string `json:"name"`
+	Description     string `json:"description"`
+	Status          string `json:"status"`
+	TasklistID      ID     `json:"tasklistId"`
+	ParentTaskID    ID     `json:"parentTaskId"`
+	TagIDs          []ID   `json:"tagIds"`
+	EstimateMinutes int    `json:"estimateMinutes"`
+
+	// The fields below are resolved from the included data
+	ProjectID      ID     `json:"projectId"`
+	ProjectName    string `json:"projectName,omitempty"`
+	TasklistName   string `json:"tasklistName,omitempty"`
+	ParentTaskName string `json:"parentTaskName,omitempty"`
+	Tags           []Tag  `json:"tags,omitempty"`
 }
 
-type TaskProject struct {
-	ID   uint64 `json:"id"`
-	Name string `json:"name"`
+type Tasklist struct {
+	ID        ID     `json:"id"`
+	Name      string `json:"name"`
+	ProjectID ID     `json:"projectId"`
+}
+
+type Tag struct {
+	ID    ID     `json:"id"`
+	Name  string `json:"name"`
+	Color string `json:"color"`
 }
 
 type TasksGroup struct {
-	Project TaskProject
+	Project Project
 	Tasks   []Task
 }
 
+// resolveIncluded fills the fields of the tasks taken from the included data
+func (tr *TasksResponse) resolveIncluded() {
+	for i := range tr.Tasks {
+		task := &tr.Tasks[i]
+
+		tasklist := tr.Included.Tasklists[task.TasklistID.String()]
+		task.TasklistName = tasklist.Name
+		if task.ProjectID == 0 {
+			task.ProjectID = tasklist.ProjectID
+		}
+		task.ProjectName = tr.Included.Projects[task.ProjectID.String()].Name
+
+		if task.ParentTaskID != 0 {
+			task.ParentTaskName = tr.Included.ParentTasks[task.ParentTaskID.String()].Name
+		}
+
+		task.Tags = nil
+		for _, tagID := range task.TagIDs {
+			if tag, ok := tr.Included.Tags[tagID.String()]; ok {
+				task.Tags = append(task.Tags, tag)
+			}
+		}
+	}
+}
+
+// GroupByProject returns the tasks grouped by project, ordered by project name
 func (tr *TasksResponse) GroupByProject() []TasksGroup {
-	groupMap := make(map[uint64][]Task)
+	groupMap := make(map[ID][]Task)
 
 	for _, task := range tr.Tasks {
 		groupMap[task.ProjectID] = append(groupMap[task.ProjectID], task)
@@ -39,7 +126,7 @@ func (tr *TasksResponse) GroupByProject() []TasksGroup {
 
 	for projectID, tasks := range groupMap {
 		groups = append(groups, TasksGroup{
-			Project: TaskProject{
+			Project: Project{
 				ID:   projectID,
 				Name: tasks[0].ProjectName,
 			},
@@ -47,5 +134,9 @@ func (tr *TasksResponse) GroupByProject() []TasksGroup {
 		})
 	}
 
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i].Project.Name < groups[j].Project.Name
+	})
+
 	return groups
 }
@@ -37,7 +37,7 @@ type TimeChart struct {
 	StartEpoch TimeX `json:"startepoch"`
 	EndEpoch   TimeX `json:"endepoch"`
 
-	Id        string `json:"id"`
+	Id        ID     `json:"id"`
 	FirstName string `json:"firstname"`
 	LastName  string `json:"lastname"`
 }
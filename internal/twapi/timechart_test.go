@@ -76,7 +76,7 @@ func TestTimeChartResponse_UnmarshalJSON(t *testing.T) {
 					Min:   0,
 				},
 			},
-			Id:        "123456",
+			Id:        123456,
 			FirstName: "John",
 			LastName:  "Smith",
 		},
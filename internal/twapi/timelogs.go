@@ -0,0 +1,146 @@
+package twapi
+
+import "time"
+
+/*
+
+Example of TimelogsResponse
+(API v3, projects/api/v3/time.json?startDate=2023-03-01&endDate=2023-03-31&include=projects,tasks):
+
+{
+    "timelogs": [
+        {
+            "id": 3074021,
+            "projectId": 548295,
+            "taskId": 26658918,
+            "userId": 410378,
+            "minutes": 450,
+            "description": "Feature",
+            "isBillable": true,
+            "timeLogged": "2023-03-01T09:00:00Z"
+        }
+    ],
+    "included": {
+        "projects": {
+            "548295": {"id": 548295, "name": "Website"}
+        },
+        "tasks": {
+            "26658918": {"id": 26658918, "name": "Development"}
+        }
+    },
+    "meta": {
+        "page": {"pageOffset": 0, "pageSize": 250, "count": 1, "hasMore": false}
+    }
+}
+*/
+
+type TimelogsResponse struct {
+	Timelogs []Timelog       `json:"timelogs"`
+	Included TimelogIncluded `json:"included"`
+	Meta     Meta            `json:"meta"`
+}
+
+// TimelogResponse is a single timelog,
+// e.g. of projects/api/v3/time/3074021.json?include=projects,tasks
+type TimelogResponse struct {
+	Timelog  Timelog         `json:"timelog"`
+	Included TimelogIncluded `json:"included"`
+}
+
+// TimelogIncluded is the data sideloaded with the timelogs, by ID
+type TimelogIncluded struct {
+	Projects map[string]Project `json:"projects"`
+	Tasks    map[string]Task    `json:"tasks"`
+}
+
+// Timelog is a time entry
+type Timelog struct {
+	ID          ID     `json:"id"`
+	ProjectID   ID     `json:"projectId"`
+	TaskID      ID     `json:"taskId"`
+	UserID      ID     `json:"userId"`
+	Minutes     int    `json:"minutes"`
+	Description string `json:"description"`
+	IsBillable  bool   `json:"isBillable"`
+	// TimeLogged is the date and the start time of the logged work
+	TimeLogged time.Time `json:"timeLogged"`
+
+	// The fields below are resolved from the included data
+	ProjectName string `json:"projectName,omitempty"`
+	TaskName    string `json:"taskName,omitempty"`
+}
+
+// Duration returns the logged time
+func (t *Timelog) Duration() time.Duration {
+	return time.Duration(t.Minutes) * time.Minute
+}
+
+// TimelogUpdate holds the changed fields of a timelog, the nil fields are kept.
+// The time is changed by setting both Hours and Minutes.
+type TimelogUpdate struct {
+	TaskID  *ID     `json:"taskId,omitempty"`
+	Hours   *uint64 `json:"hours,omitempty"`
+	Minutes *uint64 `json:"minutes,omitempty"`
+	// Date is in the format YYYY-MM-DD
+	Date *string `json:"date,omitempty"`
+	// Time is in the format HH:MM:SS
+	Time        *string `json:"time,omitempty"`
+	Description *string `json:"description,omitempty"`
+	IsBillable  *bool   `json:"isBillable,omitempty"`
+}
+
+type TimelogUpdateRequest struct {
+	Timelog TimelogUpdate `json:"timelog"`
+}
+
+// TimelogsFilter selects the timelogs, the zero fields select all of them
+type TimelogsFilter struct {
+	// From and To are the first and the last day of the range, inclusive
+	From time.Time
+	To   time.Time
+
+	UserID    ID
+	ProjectID ID
+	TaskID    ID
+}
+
+func (f *TimelogsFilter) queryParams() map[string]string {
+	params := map[string]string{}
+
+	if !f.From.IsZero() {
+		params["startDate"] = f.From.Format("2006-01-02")
+	}
+	if !f.To.IsZero() {
+		params["endDate"] = f.To.Format("2006-01-02")
+	}
+	if f.UserID != 0 {
+		params["assignedToUserIds"] = f.UserID.String()
+	}
+	if f.ProjectID != 0 {
+		params["projectIds"] = f.ProjectID.String()
+	}
+	if f.TaskID != 0 {
+		params["taskIds"] = f.TaskID.String()
+	}
+
+	return params
+}
+
+// resolveIncluded fills the fields of the timelogs taken from the included data
+func (tr *TimelogsResponse) resolveIncluded() {
+	for i := range tr.Timelogs {
+		tr.Included.resolve(&tr.Timelogs[i])
+	}
+}
+
+// resolveIncluded fills the fields of the timelog taken from the included data
+func (tr *TimelogResponse) resolveIncluded() {
+	tr.Included.resolve(&tr.Timelog)
+}
+
+func (ti *TimelogIncluded) resolve(timelog *Timelog) {
+	timelog.ProjectName = ti.Projects[timelog.ProjectID.String()].Name
+	if timelog.TaskID != 0 {
+		timelog.TaskName = ti.Tasks[timelog.TaskID.String()].Name
+	}
+}
@@ -0,0 +1,183 @@
+package twapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	redacted = "[REDACTED]"
+	// maxTracedBody limits the size of the bodies written to the trace
+	maxTracedBody = 64 * 1024
+)
+
+// sensitiveRequestFields are the JSON fields redacted in the request bodies,
+// e.g. the login password and the two-factor code
+var sensitiveRequestFields = []string{"password", "code", "twoFactorAuthToken", "token", "apiKey"}
+
+// sensitiveResponseFields are the JSON fields redacted in the response bodies,
+// e.g. the two-factor challenge token returned by the login
+var sensitiveResponseFields = []string{"twoFactorAuthToken", "token", "apiKey"}
+
+// WithTrace writes every request and response to w, including the retries.
+// The cookies, the Authorization header and the passwords are redacted,
+// so the trace can be shared.
+func WithTrace(w io.Writer) Option {
+	return func(c *client) {
+		c.http.SetTransport(&tracingTransport{
+			next: c.http.GetClient().Transport,
+			w:    w,
+		})
+	}
+}
+
+type tracingTransport struct {
+	next http.RoundTripper
+
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (t *tracingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	entry := &bytes.Buffer{}
+
+	fmt.Fprintf(entry, "--> %s %s\n", req.Method, req.URL)
+	writeHeaders(entry, req.Header)
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody != nil {
+		if body, err := req.GetBody(); err == nil && body != nil {
+			data, _ := io.ReadAll(body)
+			body.Close()
+			writeBody(entry, data, sensitiveRequestFields)
+		}
+	}
+
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	elapsed := time.Since(start).Round(time.Millisecond)
+
+	if err != nil {
+		fmt.Fprintf(entry, "<-- %s %s failed after %s: %s\n\n", req.Method, req.URL, elapsed, err)
+		t.write(entry.Bytes())
+		return nil, err
+	}
+
+	fmt.Fprintf(entry, "<-- %s %s %s (%s)\n", resp.Status, req.Method, req.URL, elapsed)
+	writeHeaders(entry, resp.Header)
+
+	// the body is read here, so it is replaced with a copy for the caller
+	data, readErr := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(data))
+	if readErr != nil {
+		fmt.Fprintf(entry, "(failed to read the body: %s)\n", readErr)
+	}
+	writeBody(entry, data, sensitiveResponseFields)
+	entry.WriteString("\n")
+
+	t.write(entry.Bytes())
+
+	return resp, readErr
+}
+
+// write writes the whole entry at once,
+// so the entries of concurrent requests are not mixed
+func (t *tracingTransport) write(entry []byte) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.w.Write(entry)
+}
+
+func writeHeaders(w io.Writer, header http.Header) {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		for _, value := range header[name] {
+			fmt.Fprintf(w, "%s: %s\n", name, redactHeader(name, value))
+		}
+	}
+}
+
+// redactHeader hides the credentials: the values of all the cookies
+// (the session is in 'tw-auth') and the API key in the Authorization header
+func redactHeader(name, value string) string {
+	switch http.CanonicalHeaderKey(name) {
+	case "Authorization":
+		scheme, _, _ := strings.Cut(value, " ")
+		return scheme + " " + redacted
+	case "Cookie":
+		cookies := strings.Split(value, ";")
+		for i, cookie := range cookies {
+			cookieName, _, _ := strings.Cut(cookie, "=")
+			cookies[i] = cookieName + "=" + redacted
+		}
+		return strings.Join(cookies, ";")
+	case "Set-Cookie":
+		cookie, attributes, _ := strings.Cut(value, ";")
+		cookieName, _, _ := strings.Cut(cookie, "=")
+		return cookieName + "=" + redacted + ";" + attributes
+	}
+
+	return value
+}
+
+func writeBody(w io.Writer, data []byte, sensitiveFields []string) {
+	if len(data) == 0 {
+		return
+	}
+
+	var document interface{}
+	if json.Unmarshal(data, &document) == nil {
+		if redactedData, err := json.Marshal(redactFields(document, sensitiveFields)); err == nil {
+			data = redactedData
+		}
+	}
+
+	if len(data) > maxTracedBody {
+		fmt.Fprintf(w, "%s... (%d bytes)\n", data[:maxTracedBody], len(data))
+		return
+	}
+
+	fmt.Fprintf(w, "%s\n", data)
+}
+
+// redactFields replaces the values of the sensitive fields at any depth of the JSON document
+func redactFields(value interface{}, sensitiveFields []string) interface{} {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		for key, field := range value {
+			if isSensitiveField(key, sensitiveFields) {
+				value[key] = redacted
+			} else {
+				value[key] = redactFields(field, sensitiveFields)
+			}
+		}
+	case []interface{}:
+		for i, item := range value {
+			value[i] = redactFields(item, sensitiveFields)
+		}
+	}
+
+	return value
+}
+
+func isSensitiveField(key string, sensitiveFields []string) bool {
+	for _, field := range sensitiveFields {
+		if strings.EqualFold(key, field) {
+			return true
+		}
+	}
+
+	return false
+}
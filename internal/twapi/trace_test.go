@@ -0,0 +1,56 @@
+package twapi_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/harnyk/teamjerk/internal/twapi"
+	"github.com/ysmood/got"
+)
+
+func TestWithTrace_RedactsCredentials(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/launchpad/v1/login.json":
+			http.SetCookie(w, &http.Cookie{Name: "tw-auth", Value: "session-secret", Path: "/"})
+			w.Write([]byte(`{"status": "OK"}`))
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"person": {"id": "42"}, "status": "OK"}`))
+		}
+	}))
+	defer server.Close()
+
+	trace := &bytes.Buffer{}
+	tw := twapi.NewClient(twapi.WithTrace(trace))
+
+	auth, err := tw.LogIn(context.Background(), server.URL+"/", "me@example.com", "password-secret")
+	got.T(t).Eq(err, nil)
+	got.T(t).Eq(auth.Token, "session-secret")
+
+	me, err := tw.GetMe(context.Background(), auth)
+	got.T(t).Eq(err, nil)
+	got.T(t).Eq(me.Person.ID, twapi.ID(42))
+
+	_, err = tw.GetMe(context.Background(), &twapi.AuthData{
+		Scheme:      twapi.AuthSchemeAPIKey,
+		APIEndPoint: server.URL + "/",
+		Token:       "api-key-secret",
+	})
+	got.T(t).Eq(err, nil)
+
+	got.T(t).Has(trace.String(), "--> POST "+server.URL+"/launchpad/v1/login.json")
+	got.T(t).Has(trace.String(), "<-- 200 OK GET "+server.URL+"/me.json")
+	got.T(t).Has(trace.String(), `"person":{"id":"42"}`)
+	got.T(t).Has(trace.String(), `"email":"me@example.com"`)
+	got.T(t).Has(trace.String(), "Set-Cookie: tw-auth=[REDACTED]")
+	got.T(t).Has(trace.String(), "Cookie: tw-auth=[REDACTED]")
+	got.T(t).Has(trace.String(), "Authorization: Basic [REDACTED]")
+
+	for _, secret := range []string{"session-secret", "password-secret", "api-key-secret"} {
+		got.T(t).Eq(bytes.Contains(trace.Bytes(), []byte(secret)), false)
+	}
+}
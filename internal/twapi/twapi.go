@@ -1,46 +1,144 @@
 package twapi
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-resty/resty/v2"
 )
 
+const (
+	// AuthSchemeCookie authenticates with the 'tw-auth' session cookie
+	// obtained by logging in with email and password
+	AuthSchemeCookie = "cookie"
+	// AuthSchemeAPIKey authenticates with a personal API key
+	// sent as the username of HTTP Basic auth
+	AuthSchemeAPIKey = "api-key"
+)
+
 type AuthData struct {
+	// Scheme is one of AuthSchemeCookie or AuthSchemeAPIKey.
+	// Empty value means AuthSchemeCookie for the data saved by older versions.
+	Scheme      string `json:",omitempty"`
 	APIEndPoint string
 	Token       string
+
+	// The login metadata below is informational only.
+	// It is missing in the data saved by older versions.
+	InstallationID   ID        `json:",omitempty"`
+	InstallationName string    `json:",omitempty"`
+	Region           string    `json:",omitempty"`
+	UserID           ID        `json:",omitempty"`
+	LoggedInAt       time.Time `json:",omitempty"`
+}
+
+func (a *AuthData) IsAPIKey() bool {
+	return a.Scheme == AuthSchemeAPIKey
 }
 
 type Client interface {
-	GetAccountsToLogIn(email, password string) (*AccountsResponse, error)
-	LogIn(apiEndPoint, email, password string) (*AuthData, error)
-	GetMe(authData *AuthData) (*ProfileResponse, error)
-	GetProjects(authData *AuthData) (*ProjectsResponse, error)
-	GetTasks(authData *AuthData) (*TasksResponse, error)
-	LogTime(authData *AuthData, timeLog *LogtimeRequestWithProjectID) error
-	GetLoggedTime(authData *AuthData, beginningOfMonth time.Time) (*TimeChartResponse, error)
+	// GetAccountsToLogIn returns TwoFactorRequiredError if the code is required
+	// before the accounts are listed, use GetAccountsWithSession once it's verified
+	GetAccountsToLogIn(ctx context.Context, email, password string) (*AccountsResponse, error)
+	GetAccountsWithSession(ctx context.Context, session *AuthData) (*AccountsResponse, error)
+	LogIn(ctx context.Context, apiEndPoint, email, password string) (*AuthData, error)
+	VerifyTwoFactor(ctx context.Context, challenge *TwoFactorChallenge, code string) (*AuthData, error)
+	LogOut(ctx context.Context, authData *AuthData) error
+	GetMe(ctx context.Context, authData *AuthData) (*ProfileResponse, error)
+	// GetProjects returns the projects of all the pages,
+	// use ProjectsPager to process them page by page
+	GetProjects(ctx context.Context, authData *AuthData) (*ProjectsResponse, error)
+	ProjectsPager(ctx context.Context, authData *AuthData) *Pager[Project]
+	// GetTasks returns the tasks of all the pages,
+	// use TasksPager to process them page by page
+	GetTasks(ctx context.Context, authData *AuthData) (*TasksResponse, error)
+	TasksPager(ctx context.Context, authData *AuthData) *Pager[Task]
+	// LogTime logs the time on the task, or on the project if the task is not set,
+	// and returns the created timelog
+	LogTime(ctx context.Context, authData *AuthData, timeLog *LogtimeRequestWithProjectID) (*Timelog, error)
+	// GetTimelogs returns the time entries selected by the filter from all the pages,
+	// use TimelogsPager to process them page by page
+	GetTimelogs(ctx context.Context, authData *AuthData, filter TimelogsFilter) ([]Timelog, error)
+	TimelogsPager(ctx context.Context, authData *AuthData, filter TimelogsFilter) *Pager[Timelog]
+	GetTimelog(ctx context.Context, authData *AuthData, timelogID ID) (*Timelog, error)
+	// UpdateTimelog changes the fields of the timelog set in the update
+	// and returns the updated timelog
+	UpdateTimelog(ctx context.Context, authData *AuthData, timelogID ID, update *TimelogUpdate) (*Timelog, error)
+	DeleteTimelog(ctx context.Context, authData *AuthData, timelogID ID) error
+	// GetLoggedTime returns the time logged by the user in the month
+	GetLoggedTime(ctx context.Context, authData *AuthData, userID ID, beginningOfMonth time.Time) (*TimeChartResponse, error)
 }
 
+const defaultLaunchpadURL = "https://www.teamwork.com"
+
 type client struct {
+	// http is shared by all requests to reuse the connections
+	http *resty.Client
+	// transport is the one used by http, kept to be configured by the options
+	transport *http.Transport
+
+	launchpadURL string
+	// apiEndPointOverride replaces the API endpoint of the installation if set
+	apiEndPointOverride string
 }
 
-func NewClient() Client {
-	return &client{}
+func NewClient(options ...Option) Client {
+	transport := newTransport()
+	c := &client{
+		http:         newHTTPClient(transport),
+		transport:    transport,
+		launchpadURL: defaultLaunchpadURL,
+	}
+
+	for _, option := range options {
+		option(c)
+	}
+
+	return c
+}
+
+// WithLaunchpadURL sets the URL of the launchpad used to find the accounts
+// on login, e.g. for a staging environment
+func WithLaunchpadURL(launchpadURL string) Option {
+	return func(c *client) {
+		c.launchpadURL = strings.TrimSuffix(launchpadURL, "/")
+	}
+}
+
+// WithAPIEndPoint sends the requests to the given endpoint
+// instead of the API endpoint of the installation,
+// e.g. to go through a reverse proxy
+func WithAPIEndPoint(apiEndPoint string) Option {
+	return func(c *client) {
+		if apiEndPoint != "" && !strings.HasSuffix(apiEndPoint, "/") {
+			apiEndPoint += "/"
+		}
+		c.apiEndPointOverride = apiEndPoint
+	}
 }
 
 func (c *client) baseURL() string {
-	return "https://www.teamwork.com"
+	return c.launchpadURL
 }
 
-func (c *client) GetAccountsToLogIn(email, password string) (*AccountsResponse, error) {
-	client := resty.New()
+// endPoint returns the API endpoint the requests for the installation are sent to
+func (c *client) endPoint(apiEndPoint string) string {
+	if c.apiEndPointOverride != "" {
+		return c.apiEndPointOverride
+	}
 
+	return apiEndPoint
+}
+
+func (c *client) GetAccountsToLogIn(ctx context.Context, email, password string) (*AccountsResponse, error) {
 	accountsResponse := &AccountsResponse{}
 
-	resp, err := client.R().
+	resp, err := c.http.R().
+		SetContext(ctx).
 		SetHeader("Content-Type", "application/json").
 		SetBody(map[string]interface{}{
 			"email":      email,
@@ -54,39 +152,100 @@ func (c *client) GetAccountsToLogIn(email, password string) (*AccountsResponse,
 		return nil, err
 	}
 
-	if resp.StatusCode() != http.StatusOK {
-		return nil, fmt.Errorf("status code: %d", resp.StatusCode())
+	// the challenge is verified with the launchpad, not with an installation
+	if challenge := parseTwoFactorChallenge(c.baseURL()+"/", resp.Body()); challenge != nil {
+		return nil, &TwoFactorRequiredError{Challenge: challenge}
+	}
+
+	if err := checkStatus(resp, http.StatusOK); err != nil {
+		return nil, err
 	}
 
 	return accountsResponse, nil
 }
 
-func (c *client) LogIn(apiEndPoint, email, password string) (*AuthData, error) {
+// GetAccountsWithSession lists the accounts like GetAccountsToLogIn,
+// authenticated with the session of the verified two-factor challenge
+func (c *client) GetAccountsWithSession(ctx context.Context, session *AuthData) (*AccountsResponse, error) {
+	accountsResponse := &AccountsResponse{}
+
+	resp, err := c.getAuthenticatedRequest(ctx, session).
+		SetBody(map[string]interface{}{
+			"rememberMe": true,
+		}).
+		SetResult(&accountsResponse).
+		Post(c.baseURL() + "/launchpad/v1/accounts.json?generic=true")
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkStatus(resp, http.StatusOK); err != nil {
+		return nil, err
+	}
+
+	return accountsResponse, nil
+}
+
+func (c *client) LogIn(ctx context.Context, apiEndPoint, email, password string) (*AuthData, error) {
 	// Plan:
 	// 1. POST to https://{{apiEndPoint}}launchpad/v1/login.json
 	//  with body: {"email": email, "password": password, "rememberMe": false}
-	// 2. If response status is 200, return AuthData with token from response cookie 'tw-auth'
-	// 3. If response status is not 200, return error
-
-	client := resty.New()
+	// 2. If the response contains a two-factor challenge, return TwoFactorRequiredError
+	// 3. If response status is 200, return AuthData with token from response cookie 'tw-auth'
+	// 4. If response status is not 200, return error
 
-	resp, err := client.R().
+	resp, err := c.http.R().
+		SetContext(ctx).
 		SetHeader("Content-Type", "application/json").
 		SetBody(map[string]interface{}{
 			"email":      email,
 			"password":   password,
 			"rememberMe": true,
 		}).
-		Post(apiEndPoint + "launchpad/v1/login.json")
+		Post(c.endPoint(apiEndPoint) + "launchpad/v1/login.json")
+
+	if err != nil {
+		return nil, err
+	}
+
+	// with two-factor authentication enabled, the login is not complete yet:
+	// the response contains a challenge token instead of the 'tw-auth' cookie
+	if challenge := parseTwoFactorChallenge(apiEndPoint, resp.Body()); challenge != nil {
+		return nil, &TwoFactorRequiredError{Challenge: challenge}
+	}
+
+	if err := checkStatus(resp, http.StatusOK); err != nil {
+		return nil, err
+	}
+
+	return authDataFromCookies(apiEndPoint, resp)
+}
+
+func (c *client) VerifyTwoFactor(ctx context.Context, challenge *TwoFactorChallenge, code string) (*AuthData, error) {
+	resp, err := c.http.R().
+		SetContext(ctx).
+		SetHeader("Content-Type", "application/json").
+		SetBody(map[string]interface{}{
+			"twoFactorAuthToken": challenge.Token,
+			"code":               code,
+			"rememberMe":         true,
+		}).
+		Post(c.endPoint(challenge.APIEndPoint) + "launchpad/v1/login/twofactor.json")
 
 	if err != nil {
 		return nil, err
 	}
 
-	if resp.StatusCode() != http.StatusOK {
-		return nil, fmt.Errorf("status code: %d", resp.StatusCode())
+	if err := checkStatus(resp, http.StatusOK); err != nil {
+		return nil, err
 	}
 
+	return authDataFromCookies(challenge.APIEndPoint, resp)
+}
+
+// authDataFromCookies returns AuthData with the token from the 'tw-auth' cookie
+func authDataFromCookies(apiEndPoint string, resp *resty.Response) (*AuthData, error) {
 	// return &AuthData{Token: resp.Cookies()[0].Value}, nil
 	// This is wrong, because we can have multiple cookies. Let's use the cookie package to parse the cookies:
 	cookies := resp.Cookies()
@@ -102,125 +261,268 @@ func (c *client) LogIn(apiEndPoint, email, password string) (*AuthData, error) {
 	return nil, fmt.Errorf("cookie 'tw-auth' not found")
 }
 
-func (c *client) GetMe(authData *AuthData) (*ProfileResponse, error) {
+func (c *client) LogOut(ctx context.Context, authData *AuthData) error {
+	// The launchpad logout endpoint invalidates the session on the server,
+	// so the 'tw-auth' cookie can't be used anymore even if it leaks.
+	resp, err := c.getAuthenticatedRequest(ctx, authData).
+		Get(c.endPoint(authData.APIEndPoint) + "launchpad/v1/logout.json")
+
+	if err != nil {
+		return err
+	}
+
+	return checkStatus(resp, http.StatusOK, http.StatusNoContent)
+}
+
+func (c *client) GetMe(ctx context.Context, authData *AuthData) (*ProfileResponse, error) {
 	user := &ProfileResponse{}
 
-	resp, err := c.getAuthenticatedRequest(authData).
+	resp, err := c.getAuthenticatedRequest(ctx, authData).
 		SetResult(user).
-		Get(authData.APIEndPoint + "me.json")
+		Get(c.endPoint(authData.APIEndPoint) + "me.json")
 
 	if err != nil {
 		return nil, err
 	}
 
-	if resp.StatusCode() != http.StatusOK {
-		return nil, fmt.Errorf("status code: %d", resp.StatusCode())
+	if err := checkStatus(resp, http.StatusOK); err != nil {
+		return nil, err
 	}
 
 	return user, nil
 }
 
-func (c *client) GetProjects(authData *AuthData) (*ProjectsResponse, error) {
-	projects := &ProjectsResponse{}
-
-	resp, err := c.getAuthenticatedRequest(authData).
-		SetResult(projects).
-		Get(authData.APIEndPoint + "projects.json")
-
+func (c *client) GetProjects(ctx context.Context, authData *AuthData) (*ProjectsResponse, error) {
+	projects, err := c.ProjectsPager(ctx, authData).All()
 	if err != nil {
 		return nil, err
 	}
 
-	if resp.StatusCode() != http.StatusOK {
-		return nil, fmt.Errorf("status code: %d", resp.StatusCode())
-	}
-
-	return projects, nil
+	return &ProjectsResponse{Projects: projects}, nil
 }
 
-func (c *client) GetTasks(authData *AuthData) (*TasksResponse, error) {
-	tasks := &TasksResponse{}
+func (c *client) ProjectsPager(ctx context.Context, authData *AuthData) *Pager[Project] {
+	return newPager(func(page int) ([]Project, bool, error) {
+		projects := &ProjectsResponse{}
 
-	resp, err := c.getAuthenticatedRequest(authData).
-		SetResult(tasks).
-		Get(authData.APIEndPoint + "tasks.json")
+		resp, err := c.getAuthenticatedRequest(ctx, authData).
+			SetResult(projects).
+			SetQueryParams(pageParams(page, defaultPageSize)).
+			SetQueryParam("include", "companies").
+			Get(c.endPoint(authData.APIEndPoint) + "projects/api/v3/projects.json")
 
+		if err != nil {
+			return nil, false, err
+		}
+
+		if err := checkStatus(resp, http.StatusOK); err != nil {
+			return nil, false, err
+		}
+
+		projects.resolveIncluded()
+
+		return projects.Projects, projects.Meta.Page.HasMore, nil
+	})
+}
+
+func (c *client) GetTasks(ctx context.Context, authData *AuthData) (*TasksResponse, error) {
+	tasks, err := c.TasksPager(ctx, authData).All()
 	if err != nil {
 		return nil, err
 	}
 
-	if resp.StatusCode() != http.StatusOK {
-		return nil, fmt.Errorf("status code: %d", resp.StatusCode())
-	}
+	return &TasksResponse{Tasks: tasks}, nil
+}
+
+func (c *client) TasksPager(ctx context.Context, authData *AuthData) *Pager[Task] {
+	return newPager(func(page int) ([]Task, bool, error) {
+		tasks := &TasksResponse{}
 
-	return tasks, nil
+		resp, err := c.getAuthenticatedRequest(ctx, authData).
+			SetResult(tasks).
+			SetQueryParams(pageParams(page, defaultPageSize)).
+			SetQueryParam("include", "projects,tasklists,tags,parentTasks").
+			Get(c.endPoint(authData.APIEndPoint) + "projects/api/v3/tasks.json")
+
+		if err != nil {
+			return nil, false, err
+		}
+
+		if err := checkStatus(resp, http.StatusOK); err != nil {
+			return nil, false, err
+		}
+
+		tasks.resolveIncluded()
+
+		return tasks.Tasks, tasks.Meta.Page.HasMore, nil
+	})
 }
 
-func (c *client) LogTime(authData *AuthData, timeLog *LogtimeRequestWithProjectID) error {
+func (c *client) LogTime(ctx context.Context, authData *AuthData, timeLog *LogtimeRequestWithProjectID) (*Timelog, error) {
 	var url string
 	if timeLog.Timelog.TaskID != 0 {
-		url = fmt.Sprintf("%sprojects/api/v3/tasks/%d/time.json", authData.APIEndPoint, timeLog.Timelog.TaskID)
+		url = fmt.Sprintf("%sprojects/api/v3/tasks/%d/time.json", c.endPoint(authData.APIEndPoint), timeLog.Timelog.TaskID)
 	} else {
-		url = fmt.Sprintf("%sprojects/api/v3/projects/%d/time.json", authData.APIEndPoint, timeLog.ProjectID)
+		url = fmt.Sprintf("%sprojects/api/v3/projects/%d/time.json", c.endPoint(authData.APIEndPoint), timeLog.ProjectID)
 	}
 
-	resp, err := c.getAuthenticatedRequest(authData).
+	timelog := &TimelogResponse{}
+
+	resp, err := c.getAuthenticatedRequest(ctx, authData).
+		SetResult(timelog).
 		SetBody(timeLog.LogtimeRequest).
 		Post(url)
 
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	if resp.StatusCode() != http.StatusCreated {
-		return fmt.Errorf("status code: %d", resp.StatusCode())
+	if err := checkStatus(resp, http.StatusCreated); err != nil {
+		return nil, err
 	}
 
-	return nil
+	timelog.resolveIncluded()
+
+	return &timelog.Timelog, nil
 }
 
-func (c *client) getAuthenticatedRequest(authData *AuthData) *resty.Request {
-	client := resty.New()
+func (c *client) GetTimelogs(ctx context.Context, authData *AuthData, filter TimelogsFilter) ([]Timelog, error) {
+	return c.TimelogsPager(ctx, authData, filter).All()
+}
 
-	return client.R().
-		SetHeader("Content-Type", "application/json").
-		SetCookie(&http.Cookie{
-			Name:  "tw-auth",
-			Value: authData.Token,
-		})
+func (c *client) TimelogsPager(ctx context.Context, authData *AuthData, filter TimelogsFilter) *Pager[Timelog] {
+	return newPager(func(page int) ([]Timelog, bool, error) {
+		timelogs := &TimelogsResponse{}
+
+		resp, err := c.getAuthenticatedRequest(ctx, authData).
+			SetResult(timelogs).
+			SetQueryParams(pageParams(page, defaultPageSize)).
+			SetQueryParams(filter.queryParams()).
+			SetQueryParam("include", "projects,tasks").
+			Get(c.endPoint(authData.APIEndPoint) + "projects/api/v3/time.json")
+
+		if err != nil {
+			return nil, false, err
+		}
+
+		if err := checkStatus(resp, http.StatusOK); err != nil {
+			return nil, false, err
+		}
+
+		timelogs.resolveIncluded()
+
+		return timelogs.Timelogs, timelogs.Meta.Page.HasMore, nil
+	})
 }
 
-func (c *client) GetLoggedTime(authData *AuthData, beginningOfMonth time.Time) (*TimeChartResponse, error) {
-	timeChart := &TimeChartResponse{}
+func (c *client) GetTimelog(ctx context.Context, authData *AuthData, timelogID ID) (*Timelog, error) {
+	timelog := &TimelogResponse{}
 
-	month := beginningOfMonth.Month()
-	year := beginningOfMonth.Year()
-	projectID := 0
-	page := 1
-	pageSize := 50
+	resp, err := c.getAuthenticatedRequest(ctx, authData).
+		SetResult(timelog).
+		SetQueryParam("include", "projects,tasks").
+		Get(fmt.Sprintf("%sprojects/api/v3/time/%d.json", c.endPoint(authData.APIEndPoint), timelogID))
 
-	user, err := c.GetMe(authData)
 	if err != nil {
 		return nil, err
 	}
 
-	userID := user.Person.ID
+	if err := checkStatus(resp, http.StatusOK); err != nil {
+		return nil, err
+	}
+
+	timelog.resolveIncluded()
 
-	resp, err := c.getAuthenticatedRequest(authData).
-		SetResult(timeChart).
-		SetQueryParam("m", strconv.Itoa(int(month))).
-		SetQueryParam("y", strconv.Itoa(year)).
-		SetQueryParam("projectId", strconv.Itoa(projectID)).
-		SetQueryParam("page", strconv.Itoa(page)).
-		SetQueryParam("pageSize", strconv.Itoa(pageSize)).
-		Get(authData.APIEndPoint + "people/" + userID + "/loggedtime.json")
+	return &timelog.Timelog, nil
+}
+
+func (c *client) UpdateTimelog(ctx context.Context, authData *AuthData, timelogID ID, update *TimelogUpdate) (*Timelog, error) {
+	timelog := &TimelogResponse{}
+
+	resp, err := c.getAuthenticatedRequest(ctx, authData).
+		SetResult(timelog).
+		SetBody(&TimelogUpdateRequest{Timelog: *update}).
+		SetQueryParam("include", "projects,tasks").
+		Patch(fmt.Sprintf("%sprojects/api/v3/time/%d.json", c.endPoint(authData.APIEndPoint), timelogID))
 
 	if err != nil {
 		return nil, err
 	}
 
-	if resp.StatusCode() != http.StatusOK {
-		return nil, fmt.Errorf("status code: %d", resp.StatusCode())
+	if err := checkStatus(resp, http.StatusOK); err != nil {
+		return nil, err
+	}
+
+	timelog.resolveIncluded()
+
+	return &timelog.Timelog, nil
+}
+
+func (c *client) DeleteTimelog(ctx context.Context, authData *AuthData, timelogID ID) error {
+	resp, err := c.getAuthenticatedRequest(ctx, authData).
+		Delete(fmt.Sprintf("%sprojects/api/v3/time/%d.json", c.endPoint(authData.APIEndPoint), timelogID))
+
+	if err != nil {
+		return err
+	}
+
+	return checkStatus(resp, http.StatusNoContent, http.StatusOK)
+}
+
+func (c *client) getAuthenticatedRequest(ctx context.Context, authData *AuthData) *resty.Request {
+	request := c.http.R().
+		SetContext(ctx).
+		SetHeader("Content-Type", "application/json")
+
+	if authData.IsAPIKey() {
+		// Teamwork ignores the password, any value will do
+		return request.SetBasicAuth(authData.Token, "x")
+	}
+
+	return request.SetCookie(&http.Cookie{
+		Name:  "tw-auth",
+		Value: authData.Token,
+	})
+}
+
+func (c *client) GetLoggedTime(ctx context.Context, authData *AuthData, userID ID, beginningOfMonth time.Time) (*TimeChartResponse, error) {
+	month := beginningOfMonth.Month()
+	year := beginningOfMonth.Year()
+	projectID := 0
+	pageSize := 50
+
+	var timeChart *TimeChartResponse
+
+	// the entries of all the pages are merged into the first one
+	for page := 1; ; page++ {
+		pageTimeChart := &TimeChartResponse{}
+
+		resp, err := c.getAuthenticatedRequest(ctx, authData).
+			SetResult(pageTimeChart).
+			SetQueryParam("m", strconv.Itoa(int(month))).
+			SetQueryParam("y", strconv.Itoa(year)).
+			SetQueryParam("projectId", strconv.Itoa(projectID)).
+			SetQueryParams(pageParams(page, pageSize)).
+			Get(c.endPoint(authData.APIEndPoint) + "people/" + userID.String() + "/loggedtime.json")
+
+		if err != nil {
+			return nil, err
+		}
+
+		if err := checkStatus(resp, http.StatusOK); err != nil {
+			return nil, err
+		}
+
+		if timeChart == nil {
+			timeChart = pageTimeChart
+		} else {
+			timeChart.User.Billable = append(timeChart.User.Billable, pageTimeChart.User.Billable...)
+			timeChart.User.NonBillable = append(timeChart.User.NonBillable, pageTimeChart.User.NonBillable...)
+		}
+
+		if !hasMorePages(resp, page) {
+			break
+		}
 	}
 
 	return timeChart, nil
@@ -0,0 +1,67 @@
+package twapi
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+/*
+
+Example of the login response when two-factor authentication is enabled:
+
+{
+    "status": "ok",
+    "twoFactorAuthRequired": true,
+    "twoFactorAuthToken": "f1d2d2f924e986ac86fdf7b36c94bcdf32beec15"
+}
+
+The 'tw-auth' cookie is set only after the code is verified
+with the token at launchpad/v1/login/twofactor.json
+*/
+
+// ErrTwoFactorRequired matches TwoFactorRequiredError with errors.Is
+var ErrTwoFactorRequired = errors.New("two-factor authentication code required")
+
+type TwoFactorChallenge struct {
+	APIEndPoint string
+	Token       string
+}
+
+// TwoFactorRequiredError is returned by GetAccountsToLogIn and LogIn
+// when the account requires a second factor. The challenge is passed
+// to VerifyTwoFactor together with the code.
+type TwoFactorRequiredError struct {
+	Challenge *TwoFactorChallenge
+}
+
+func (e *TwoFactorRequiredError) Error() string {
+	return ErrTwoFactorRequired.Error()
+}
+
+func (e *TwoFactorRequiredError) Is(target error) bool {
+	return target == ErrTwoFactorRequired
+}
+
+type loginResponse struct {
+	Status                string `json:"status"`
+	TwoFactorAuthRequired bool   `json:"twoFactorAuthRequired"`
+	TwoFactorAuthToken    string `json:"twoFactorAuthToken"`
+}
+
+// parseTwoFactorChallenge returns the challenge if the login response
+// requires a second factor, nil otherwise
+func parseTwoFactorChallenge(apiEndPoint string, body []byte) *TwoFactorChallenge {
+	response := &loginResponse{}
+	if err := json.Unmarshal(body, response); err != nil {
+		return nil
+	}
+
+	if !response.TwoFactorAuthRequired || response.TwoFactorAuthToken == "" {
+		return nil
+	}
+
+	return &TwoFactorChallenge{
+		APIEndPoint: apiEndPoint,
+		Token:       response.TwoFactorAuthToken,
+	}
+}
@@ -0,0 +1,984 @@
+// Package twfake is an in-memory fake of the Teamwork API used by teamjerk.
+// It serves both the launchpad and the installation endpoints,
+// so the tests and the sandbox can run the whole CLI without the real Teamwork:
+//
+//	fake := twfake.NewServer()
+//	server := httptest.NewServer(fake)
+//	defer server.Close()
+//
+//	tw := twapi.NewClient(twapi.WithLaunchpadURL(server.URL))
+package twfake
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The credentials of the only user of the fake
+const (
+	Email    = "jane.doe@example.com"
+	Password = "secret"
+	APIKey   = "twp_sandbox"
+
+	UserID           = 1001
+	FirstName        = "Jane"
+	LastName         = "Doe"
+	CompanyName      = "Example Ltd"
+	InstallationID   = 2001
+	InstallationName = "Example Ltd"
+)
+
+type Project struct {
+	ID   uint64
+	Name string
+}
+
+type Tasklist struct {
+	ID        uint64
+	ProjectID uint64
+	Name      string
+}
+
+type Tag struct {
+	ID    uint64
+	Name  string
+	Color string
+}
+
+type Task struct {
+	ID              uint64
+	ProjectID       uint64
+	TasklistID      uint64
+	ParentTaskID    uint64
+	Name            string
+	EstimateMinutes int
+	TagIDs          []uint64
+}
+
+type TimeEntry struct {
+	ID          uint64
+	ProjectID   uint64
+	TaskID      uint64
+	UserID      uint64
+	Date        string // YYYY-MM-DD
+	Time        string // HH:MM:SS
+	Minutes     uint64
+	Description string
+	IsBillable  bool
+}
+
+type Server struct {
+	// OTP is the two-factor authentication code,
+	// if set, the login requires it
+	OTP string
+	// OTPForAccounts makes the listing of the accounts require the code as well,
+	// the accounts are listed again with the session of the verified challenge
+	OTPForAccounts bool
+	// LockedDates are the dates (YYYY-MM-DD) the time can't be logged on,
+	// like in the locked timesheets
+	LockedDates []string
+	// Location is the timezone of the user, the dates and the times are logged in it.
+	// Like the real API, the fake returns the time of the entries in UTC.
+	// Nil means UTC.
+	Location *time.Location
+
+	mu         sync.Mutex
+	projects   []Project
+	tasklists  []Tasklist
+	tags       []Tag
+	tasks      []Task
+	entries    []TimeEntry
+	sessions   map[string]bool
+	challenges map[string]bool
+	lastID     uint64
+	// writesBeforeExpiry is set by SetExpireSessionsAfter, zero means never
+	writesBeforeExpiry int
+	// requests counts the requests by "METHOD /path"
+	requests map[string]int
+}
+
+// NewServer returns the fake with a few projects and tasks and no time logged
+func NewServer() *Server {
+	return &Server{
+		projects: []Project{
+			{ID: 101, Name: "Internal"},
+			{ID: 102, Name: "Website"},
+		},
+		tasklists: []Tasklist{
+			{ID: 401, ProjectID: 101, Name: "General"},
+			{ID: 402, ProjectID: 102, Name: "Sprint 12"},
+		},
+		tags: []Tag{
+			{ID: 501, Name: "backend", Color: "#4461d7"},
+		},
+		tasks: []Task{
+			{ID: 201, ProjectID: 101, TasklistID: 401, Name: "Meetings"},
+			{ID: 202, ProjectID: 102, TasklistID: 402, Name: "Development", EstimateMinutes: 480},
+			{ID: 203, ProjectID: 102, TasklistID: 402, ParentTaskID: 202, Name: "Code review", TagIDs: []uint64{501}},
+		},
+		sessions:   map[string]bool{},
+		challenges: map[string]bool{},
+		lastID:     3000,
+		requests:   map[string]int{},
+	}
+}
+
+// Requests returns how many times the endpoint has been requested,
+// e.g. Requests("GET", "/me.json")
+func (s *Server) Requests(method, path string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.requests[method+" "+path]
+}
+
+// Projects returns the projects of the installation
+func (s *Server) Projects() []Project {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Project{}, s.projects...)
+}
+
+// Tasks returns the tasks of all the projects
+func (s *Server) Tasks() []Task {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Task{}, s.tasks...)
+}
+
+// TimeEntries returns the logged time, in the order it was logged
+func (s *Server) TimeEntries() []TimeEntry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]TimeEntry{}, s.entries...)
+}
+
+// AddProject adds a project and returns it
+func (s *Server) AddProject(name string) Project {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	project := Project{ID: s.newID(), Name: name}
+	s.projects = append(s.projects, project)
+
+	return project
+}
+
+// AddTask adds a task to the first tasklist of the project and returns it
+func (s *Server) AddTask(projectID uint64, name string) Task {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	task := Task{ID: s.newID(), ProjectID: projectID, TasklistID: s.tasklistOf(projectID).ID, Name: name}
+	s.tasks = append(s.tasks, task)
+
+	return task
+}
+
+// ExpireSessions invalidates all the 'tw-auth' cookies issued so far
+func (s *Server) ExpireSessions() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sessions = map[string]bool{}
+}
+
+// SetExpireSessionsAfter expires the sessions once the next n time entries
+// are logged, updated or deleted, like a session revoked in the middle
+// of a command changing several entries. Zero means never.
+func (s *Server) SetExpireSessionsAfter(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.writesBeforeExpiry = n
+}
+
+// countWrite counts the changed time entry towards SetExpireSessionsAfter
+func (s *Server) countWrite() {
+	if s.writesBeforeExpiry == 0 {
+		return
+	}
+
+	s.writesBeforeExpiry--
+	if s.writesBeforeExpiry == 0 {
+		s.sessions = map[string]bool{}
+	}
+}
+
+func (s *Server) newID() uint64 {
+	s.lastID++
+	return s.lastID
+}
+
+var (
+	loggedTimePath  = regexp.MustCompile(`^/people/(\d+)/loggedtime\.json$`)
+	taskTimePath    = regexp.MustCompile(`^/projects/api/v3/tasks/(\d+)/time\.json$`)
+	projectTimePath = regexp.MustCompile(`^/projects/api/v3/projects/(\d+)/time\.json$`)
+	timelogPath     = regexp.MustCompile(`^/projects/api/v3/time/(\d+)\.json$`)
+)
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	path := r.URL.Path
+	s.requests[r.Method+" "+path]++
+
+	switch {
+	case r.Method == http.MethodPost && path == "/launchpad/v1/accounts.json":
+		s.accounts(w, r)
+		return
+	case r.Method == http.MethodPost && path == "/launchpad/v1/login.json":
+		s.logIn(w, r)
+		return
+	case r.Method == http.MethodPost && path == "/launchpad/v1/login/twofactor.json":
+		s.verifyTwoFactor(w, r)
+		return
+	}
+
+	session, ok := s.authenticate(r)
+	if !ok {
+		writeJSON(w, http.StatusUnauthorized, map[string]interface{}{
+			"MESSAGE": "Unauthorized",
+			"STATUS":  "Error",
+		})
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodGet && path == "/launchpad/v1/logout.json":
+		delete(s.sessions, session)
+		writeJSON(w, http.StatusOK, map[string]interface{}{"status": "ok"})
+	case r.Method == http.MethodGet && path == "/me.json":
+		s.me(w)
+	case r.Method == http.MethodGet && path == "/projects/api/v3/projects.json":
+		s.listProjects(w, r)
+	case r.Method == http.MethodGet && path == "/projects/api/v3/tasks.json":
+		s.listTasks(w, r)
+	case r.Method == http.MethodGet && path == "/projects/api/v3/time.json":
+		s.listTimelogs(w, r)
+	case r.Method == http.MethodGet && timelogPath.MatchString(path):
+		s.getTimelog(w, parseID(timelogPath, path))
+	case r.Method == http.MethodPatch && timelogPath.MatchString(path):
+		s.updateTimelog(w, r, parseID(timelogPath, path))
+	case r.Method == http.MethodDelete && timelogPath.MatchString(path):
+		s.deleteTimelog(w, parseID(timelogPath, path))
+	case r.Method == http.MethodPost && taskTimePath.MatchString(path):
+		s.logTime(w, r, 0, parseID(taskTimePath, path))
+	case r.Method == http.MethodPost && projectTimePath.MatchString(path):
+		s.logTime(w, r, parseID(projectTimePath, path), 0)
+	case r.Method == http.MethodGet && loggedTimePath.MatchString(path):
+		s.loggedTime(w, r, parseID(loggedTimePath, path))
+	default:
+		writeJSON(w, http.StatusNotFound, map[string]interface{}{
+			"MESSAGE": "Not found",
+			"STATUS":  "Error",
+		})
+	}
+}
+
+// authenticate accepts both the session cookie and the API key,
+// returns the session token for the former
+func (s *Server) authenticate(r *http.Request) (string, bool) {
+	if cookie, err := r.Cookie("tw-auth"); err == nil && s.sessions[cookie.Value] {
+		return cookie.Value, true
+	}
+
+	if user, _, ok := r.BasicAuth(); ok && user == APIKey {
+		return "", true
+	}
+
+	return "", false
+}
+
+type credentials struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+func (s *Server) checkCredentials(w http.ResponseWriter, r *http.Request) bool {
+	var body credentials
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Email != Email || body.Password != Password {
+		writeJSON(w, http.StatusUnauthorized, map[string]interface{}{
+			"status":  "error",
+			"message": "Invalid email or password",
+		})
+		return false
+	}
+
+	return true
+}
+
+func (s *Server) accounts(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.authenticate(r); !ok {
+		if !s.checkCredentials(w, r) {
+			return
+		}
+		if s.OTP != "" && s.OTPForAccounts {
+			s.challenge(w)
+			return
+		}
+	}
+
+	apiEndPoint := baseURL(r)
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"accounts": []interface{}{
+			map[string]interface{}{
+				"installation": map[string]interface{}{
+					"id":          InstallationID,
+					"name":        InstallationName,
+					"url":         apiEndPoint,
+					"region":      "EU",
+					"apiEndPoint": apiEndPoint,
+					"company": map[string]interface{}{
+						"id":   1,
+						"name": CompanyName,
+					},
+					"projectsEnabled": true,
+				},
+				"user": map[string]interface{}{
+					"id":        UserID,
+					"firstName": FirstName,
+					"lastName":  LastName,
+					"email":     Email,
+				},
+			},
+		},
+		"ignoredAccounts": 0,
+		"status":          "ok",
+	})
+}
+
+func (s *Server) logIn(w http.ResponseWriter, r *http.Request) {
+	if !s.checkCredentials(w, r) {
+		return
+	}
+
+	if s.OTP != "" {
+		s.challenge(w)
+		return
+	}
+
+	s.startSession(w)
+}
+
+// challenge responds with a two-factor challenge to verify with the code
+func (s *Server) challenge(w http.ResponseWriter) {
+	challenge := newToken()
+	s.challenges[challenge] = true
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"status":                "ok",
+		"twoFactorAuthRequired": true,
+		"twoFactorAuthToken":    challenge,
+	})
+}
+
+func (s *Server) verifyTwoFactor(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		TwoFactorAuthToken string `json:"twoFactorAuthToken"`
+		Code               string `json:"code"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil ||
+		!s.challenges[body.TwoFactorAuthToken] || body.Code != s.OTP {
+		writeJSON(w, http.StatusUnauthorized, map[string]interface{}{
+			"status":  "error",
+			"message": "Invalid two-factor authentication code",
+		})
+		return
+	}
+
+	delete(s.challenges, body.TwoFactorAuthToken)
+
+	s.startSession(w)
+}
+
+func (s *Server) startSession(w http.ResponseWriter) {
+	token := newToken()
+	s.sessions[token] = true
+
+	http.SetCookie(w, &http.Cookie{Name: "tw-auth", Value: token, Path: "/", HttpOnly: true})
+	writeJSON(w, http.StatusOK, map[string]interface{}{"status": "ok"})
+}
+
+func (s *Server) me(w http.ResponseWriter) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"person": map[string]interface{}{
+			"id":             strconv.Itoa(UserID),
+			"first-name":     FirstName,
+			"last-name":      LastName,
+			"email-address":  Email,
+			"user-name":      Email,
+			"company-name":   CompanyName,
+			"companyId":      "1",
+			"installationId": strconv.Itoa(InstallationID),
+			"lengthOfDay":    "8",
+		},
+		"STATUS": "OK",
+	})
+}
+
+func (s *Server) listProjects(w http.ResponseWriter, r *http.Request) {
+	items := []interface{}{}
+	for _, project := range s.projects {
+		items = append(items, map[string]interface{}{
+			"id":          project.ID,
+			"name":        project.Name,
+			"description": "",
+			"status":      "active",
+			"companyId":   1,
+		})
+	}
+
+	page, meta := paginate(r, items)
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"projects": page,
+		"included": map[string]interface{}{
+			"companies": map[string]interface{}{
+				"1": map[string]interface{}{"id": 1, "name": CompanyName},
+			},
+		},
+		"meta": meta,
+	})
+}
+
+// listTasks returns the tasks with the projects, the tasklists, the tags
+// and the parent tasks included, whatever the include parameter is
+func (s *Server) listTasks(w http.ResponseWriter, r *http.Request) {
+	items := []interface{}{}
+	for _, task := range s.tasks {
+		tagIDs := append([]uint64{}, task.TagIDs...)
+		item := map[string]interface{}{
+			"id":              task.ID,
+			"name":            task.Name,
+			"description":     "",
+			"status":          "new",
+			"tasklistId":      task.TasklistID,
+			"tagIds":          tagIDs,
+			"estimateMinutes": task.EstimateMinutes,
+		}
+		if task.ParentTaskID != 0 {
+			item["parentTaskId"] = task.ParentTaskID
+		}
+		items = append(items, item)
+	}
+
+	projects := map[string]interface{}{}
+	for _, project := range s.projects {
+		projects[strconv.FormatUint(project.ID, 10)] = map[string]interface{}{
+			"id":   project.ID,
+			"name": project.Name,
+		}
+	}
+	tasklists := map[string]interface{}{}
+	for _, tasklist := range s.tasklists {
+		tasklists[strconv.FormatUint(tasklist.ID, 10)] = map[string]interface{}{
+			"id":        tasklist.ID,
+			"name":      tasklist.Name,
+			"projectId": tasklist.ProjectID,
+		}
+	}
+	tags := map[string]interface{}{}
+	for _, tag := range s.tags {
+		tags[strconv.FormatUint(tag.ID, 10)] = map[string]interface{}{
+			"id":    tag.ID,
+			"name":  tag.Name,
+			"color": tag.Color,
+		}
+	}
+	parentTasks := map[string]interface{}{}
+	for _, task := range s.tasks {
+		if parent, ok := s.findTask(task.ParentTaskID); ok {
+			parentTasks[strconv.FormatUint(parent.ID, 10)] = map[string]interface{}{
+				"id":   parent.ID,
+				"name": parent.Name,
+			}
+		}
+	}
+
+	page, meta := paginate(r, items)
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"tasks": page,
+		"included": map[string]interface{}{
+			"projects":    projects,
+			"tasklists":   tasklists,
+			"tags":        tags,
+			"parentTasks": parentTasks,
+		},
+		"meta": meta,
+	})
+}
+
+type timelogRequest struct {
+	Timelog struct {
+		TaskID      uint64 `json:"taskId"`
+		Hours       uint64 `json:"hours"`
+		Minutes     uint64 `json:"minutes"`
+		Date        string `json:"date"`
+		Time        string `json:"time"`
+		Description string `json:"description"`
+		IsBillable  bool   `json:"isBillable"`
+		UserID      uint64 `json:"userId"`
+	} `json:"timelog"`
+}
+
+// logTime logs the time either on the task (taskID != 0) or on the project
+func (s *Server) logTime(w http.ResponseWriter, r *http.Request, projectID, taskID uint64) {
+	var body timelogRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid body: "+err.Error())
+		return
+	}
+
+	if taskID != 0 {
+		task, ok := s.findTask(taskID)
+		if !ok {
+			writeError(w, http.StatusNotFound, "task not found")
+			return
+		}
+		projectID = task.ProjectID
+	} else if s.findProject(projectID).ID == 0 {
+		writeError(w, http.StatusNotFound, "project not found")
+		return
+	}
+
+	timelog := body.Timelog
+
+	if _, err := time.Parse("2006-01-02", timelog.Date); err != nil {
+		writeError(w, http.StatusBadRequest, "date is invalid")
+		return
+	}
+	if _, err := time.Parse("15:04:05", timelog.Time); err != nil {
+		writeError(w, http.StatusBadRequest, "time is invalid")
+		return
+	}
+	for _, locked := range s.LockedDates {
+		if timelog.Date == locked {
+			writeError(w, http.StatusUnprocessableEntity, "the timesheet is locked")
+			return
+		}
+	}
+
+	minutes := timelog.Hours*60 + timelog.Minutes
+	if minutes == 0 {
+		writeError(w, http.StatusUnprocessableEntity, "the logged time must be greater than zero")
+		return
+	}
+
+	userID := timelog.UserID
+	if userID == 0 {
+		userID = UserID
+	}
+
+	entry := TimeEntry{
+		ID:          s.newID(),
+		ProjectID:   projectID,
+		TaskID:      taskID,
+		UserID:      userID,
+		Date:        timelog.Date,
+		Time:        timelog.Time,
+		Minutes:     minutes,
+		Description: timelog.Description,
+		IsBillable:  timelog.IsBillable,
+	}
+	s.entries = append(s.entries, entry)
+	s.countWrite()
+
+	writeJSON(w, http.StatusCreated, map[string]interface{}{
+		"timelog": s.timelogJSON(entry),
+	})
+}
+
+// listTimelogs returns the time entries filtered like API v3 does,
+// ordered by date, with the projects and the tasks included
+func (s *Server) listTimelogs(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	startDate, endDate := query.Get("startDate"), query.Get("endDate")
+	userIDs := parseIDs(query.Get("assignedToUserIds"))
+	projectIDs := parseIDs(query.Get("projectIds"))
+	taskIDs := parseIDs(query.Get("taskIds"))
+
+	entries := []TimeEntry{}
+	for _, entry := range s.entries {
+		if (startDate != "" && entry.Date < startDate) ||
+			(endDate != "" && entry.Date > endDate) ||
+			(len(userIDs) > 0 && !userIDs[entry.UserID]) ||
+			(len(projectIDs) > 0 && !projectIDs[entry.ProjectID]) ||
+			(len(taskIDs) > 0 && !taskIDs[entry.TaskID]) {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Date+entries[i].Time < entries[j].Date+entries[j].Time
+	})
+
+	items := []interface{}{}
+	for _, entry := range entries {
+		items = append(items, s.timelogJSON(entry))
+	}
+
+	page, meta := paginate(r, items)
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"timelogs": page,
+		"included": s.timelogIncluded(entries...),
+		"meta":     meta,
+	})
+}
+
+func (s *Server) getTimelog(w http.ResponseWriter, id uint64) {
+	i, ok := s.findEntry(id)
+	if !ok {
+		writeError(w, http.StatusNotFound, "timelog not found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"timelog":  s.timelogJSON(s.entries[i]),
+		"included": s.timelogIncluded(s.entries[i]),
+	})
+}
+
+// updateTimelog changes the fields present in the body
+func (s *Server) updateTimelog(w http.ResponseWriter, r *http.Request, id uint64) {
+	i, ok := s.findEntry(id)
+	if !ok {
+		writeError(w, http.StatusNotFound, "timelog not found")
+		return
+	}
+
+	var body struct {
+		Timelog struct {
+			TaskID      *uint64 `json:"taskId"`
+			Hours       *uint64 `json:"hours"`
+			Minutes     *uint64 `json:"minutes"`
+			Date        *string `json:"date"`
+			Time        *string `json:"time"`
+			Description *string `json:"description"`
+			IsBillable  *bool   `json:"isBillable"`
+		} `json:"timelog"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid body: "+err.Error())
+		return
+	}
+
+	entry := s.entries[i]
+	update := body.Timelog
+
+	if update.TaskID != nil {
+		task, ok := s.findTask(*update.TaskID)
+		if !ok {
+			writeError(w, http.StatusNotFound, "task not found")
+			return
+		}
+		entry.TaskID = task.ID
+		entry.ProjectID = task.ProjectID
+	}
+	if update.Hours != nil || update.Minutes != nil {
+		var hours, minutes uint64
+		if update.Hours != nil {
+			hours = *update.Hours
+		}
+		if update.Minutes != nil {
+			minutes = *update.Minutes
+		}
+		entry.Minutes = hours*60 + minutes
+		if entry.Minutes == 0 {
+			writeError(w, http.StatusUnprocessableEntity, "the logged time must be greater than zero")
+			return
+		}
+	}
+	if update.Date != nil {
+		if _, err := time.Parse("2006-01-02", *update.Date); err != nil {
+			writeError(w, http.StatusBadRequest, "date is invalid")
+			return
+		}
+		entry.Date = *update.Date
+	}
+	if update.Time != nil {
+		if _, err := time.Parse("15:04:05", *update.Time); err != nil {
+			writeError(w, http.StatusBadRequest, "time is invalid")
+			return
+		}
+		entry.Time = *update.Time
+	}
+	if update.Description != nil {
+		entry.Description = *update.Description
+	}
+	if update.IsBillable != nil {
+		entry.IsBillable = *update.IsBillable
+	}
+
+	s.entries[i] = entry
+	s.countWrite()
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"timelog":  s.timelogJSON(entry),
+		"included": s.timelogIncluded(entry),
+	})
+}
+
+func (s *Server) deleteTimelog(w http.ResponseWriter, id uint64) {
+	i, ok := s.findEntry(id)
+	if !ok {
+		writeError(w, http.StatusNotFound, "timelog not found")
+		return
+	}
+
+	s.entries = append(s.entries[:i], s.entries[i+1:]...)
+	s.countWrite()
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// timelogIncluded returns the projects and the tasks of the time entries
+func (s *Server) timelogIncluded(entries ...TimeEntry) map[string]interface{} {
+	projects := map[string]interface{}{}
+	tasks := map[string]interface{}{}
+	for _, entry := range entries {
+		project := s.findProject(entry.ProjectID)
+		projects[strconv.FormatUint(project.ID, 10)] = map[string]interface{}{
+			"id":   project.ID,
+			"name": project.Name,
+		}
+		if task, ok := s.findTask(entry.TaskID); ok {
+			tasks[strconv.FormatUint(task.ID, 10)] = map[string]interface{}{
+				"id":   task.ID,
+				"name": task.Name,
+			}
+		}
+	}
+
+	return map[string]interface{}{
+		"projects": projects,
+		"tasks":    tasks,
+	}
+}
+
+// timelogJSON returns the time entry in the format of API v3
+func (s *Server) timelogJSON(entry TimeEntry) map[string]interface{} {
+	location := s.Location
+	if location == nil {
+		location = time.UTC
+	}
+	timeLogged, _ := time.ParseInLocation("2006-01-02 15:04:05", entry.Date+" "+entry.Time, location)
+
+	return map[string]interface{}{
+		"id":          entry.ID,
+		"projectId":   entry.ProjectID,
+		"taskId":      entry.TaskID,
+		"userId":      entry.UserID,
+		"minutes":     entry.Minutes,
+		"description": entry.Description,
+		"isBillable":  entry.IsBillable,
+		"timeLogged":  timeLogged.UTC().Format(time.RFC3339),
+	}
+}
+
+// loggedTime returns the time logged by the user in the month,
+// summed up by day, in the format of the time chart of the legacy API
+func (s *Server) loggedTime(w http.ResponseWriter, r *http.Request, userID uint64) {
+	month, err1 := strconv.Atoi(r.URL.Query().Get("m"))
+	year, err2 := strconv.Atoi(r.URL.Query().Get("y"))
+	if err1 != nil || err2 != nil || month < 1 || month > 12 {
+		writeError(w, http.StatusBadRequest, "m and y are required")
+		return
+	}
+
+	start := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 1, -1)
+
+	billable := map[string]uint64{}
+	nonBillable := map[string]uint64{}
+	for _, entry := range s.entries {
+		if entry.UserID != userID || !strings.HasPrefix(entry.Date, start.Format("2006-01-")) {
+			continue
+		}
+		if entry.IsBillable {
+			billable[entry.Date] += entry.Minutes
+		} else {
+			nonBillable[entry.Date] += entry.Minutes
+		}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"STATUS": "OK",
+		"user": map[string]interface{}{
+			"id":          strconv.FormatUint(userID, 10),
+			"firstname":   FirstName,
+			"lastname":    LastName,
+			"startepoch":  epoch(start),
+			"endepoch":    epoch(end),
+			"billable":    timeChartEntries(start, end, billable),
+			"nonbillable": timeChartEntries(start, end, nonBillable),
+		},
+	})
+}
+
+// timeChartEntries returns an entry for every day of the range:
+// the epoch in milliseconds, the hours and the minutes, all as strings
+func timeChartEntries(start, end time.Time, minutesByDate map[string]uint64) [][]string {
+	entries := [][]string{}
+
+	for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
+		minutes := minutesByDate[day.Format("2006-01-02")]
+		entries = append(entries, []string{
+			epoch(day),
+			fmt.Sprintf("%.2f", float64(minutes)/60),
+			strconv.FormatUint(minutes, 10),
+		})
+	}
+
+	return entries
+}
+
+func epoch(t time.Time) string {
+	return strconv.FormatInt(t.UnixMilli(), 10)
+}
+
+func (s *Server) findProject(id uint64) Project {
+	for _, project := range s.projects {
+		if project.ID == id {
+			return project
+		}
+	}
+
+	return Project{}
+}
+
+// findEntry returns the index of the time entry
+func (s *Server) findEntry(id uint64) (int, bool) {
+	for i, entry := range s.entries {
+		if entry.ID == id {
+			return i, true
+		}
+	}
+
+	return 0, false
+}
+
+// tasklistOf returns the first tasklist of the project,
+// adding one if the project has none
+func (s *Server) tasklistOf(projectID uint64) Tasklist {
+	for _, tasklist := range s.tasklists {
+		if tasklist.ProjectID == projectID {
+			return tasklist
+		}
+	}
+
+	tasklist := Tasklist{ID: s.newID(), ProjectID: projectID, Name: "General"}
+	s.tasklists = append(s.tasklists, tasklist)
+
+	return tasklist
+}
+
+func (s *Server) findTask(id uint64) (Task, bool) {
+	for _, task := range s.tasks {
+		if task.ID == id {
+			return task, true
+		}
+	}
+
+	return Task{}, false
+}
+
+// parseIDs parses a comma-separated list of IDs into a set
+func parseIDs(s string) map[uint64]bool {
+	ids := map[uint64]bool{}
+	for _, field := range strings.Split(s, ",") {
+		if id, err := strconv.ParseUint(field, 10, 64); err == nil {
+			ids[id] = true
+		}
+	}
+
+	return ids
+}
+
+// paginate returns the requested page of the items
+// and its metadata, like API v3
+func paginate(r *http.Request, items []interface{}) ([]interface{}, map[string]interface{}) {
+	page, err := strconv.Atoi(r.URL.Query().Get("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	pageSize, err := strconv.Atoi(r.URL.Query().Get("pageSize"))
+	if err != nil || pageSize < 1 {
+		pageSize = 50
+	}
+
+	from := (page - 1) * pageSize
+	if from > len(items) {
+		from = len(items)
+	}
+	to := from + pageSize
+	if to > len(items) {
+		to = len(items)
+	}
+
+	meta := map[string]interface{}{
+		"page": map[string]interface{}{
+			"pageOffset": page - 1,
+			"pageSize":   pageSize,
+			"count":      len(items),
+			"hasMore":    to < len(items),
+		},
+	}
+
+	return items[from:to], meta
+}
+
+// baseURL returns the URL the fake is reached at, which is its API endpoint
+func baseURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+
+	return scheme + "://" + r.Host + "/"
+}
+
+func parseID(path *regexp.Regexp, urlPath string) uint64 {
+	id, _ := strconv.ParseUint(path.FindStringSubmatch(urlPath)[1], 10, 64)
+	return id
+}
+
+func newToken() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// writeError writes the error in the format of API v3
+func writeError(w http.ResponseWriter, status int, detail string) {
+	writeJSON(w, status, map[string]interface{}{
+		"errors": []interface{}{
+			map[string]interface{}{
+				"title":  http.StatusText(status),
+				"detail": detail,
+			},
+		},
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}